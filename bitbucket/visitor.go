@@ -0,0 +1,137 @@
+package bitbucket
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/huangjiuyuan/typospider/github"
+	"github.com/huangjiuyuan/typospider/util/rest"
+)
+
+// Number of files requested in a page.
+const pageSize = 1000
+
+// Visitor is the agent for requesting the REST API of Bitbucket Server. Bitbucket Server lists all
+// files of a ref at once, so trees are always recursive, and the blobs have no SHA until they are
+// fetched.
+type Visitor struct {
+	// For authorization with an HTTP access token, which is skipped if it is empty.
+	Token string
+	// BaseURL of the REST API, like "https://bitbucket.example.com/rest/api/1.0", without a trailing
+	// slash.
+	BaseURL string
+	// HTTP client of the requests, which defaults to http.DefaultClient if it is nil.
+	Client *http.Client
+}
+
+// files is a page of the files of a ref.
+type files struct {
+	// Paths of the files.
+	Values []string `json:"values"`
+	// Whether the page is the last one.
+	IsLastPage bool `json:"isLastPage"`
+	// Start of the next page.
+	NextPageStart int `json:"nextPageStart"`
+}
+
+// NewVisitor creates a visitor for requesting the Bitbucket Server API at the base URL.
+func NewVisitor(token string, baseURL string) (*Visitor, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("cannot use an empty base URL")
+	}
+
+	github.AddSecret(token)
+	v := &Visitor{
+		Token:   token,
+		BaseURL: baseURL,
+	}
+	return v, nil
+}
+
+// GetTreeURL returns the API URL of the files of a ref in a repository, where the owner is the key
+// of the project.
+func (vis *Visitor) GetTreeURL(owner string, repo string, ref string) string {
+	return fmt.Sprintf("%s/projects/%s/repos/%s/files?at=%s", strings.TrimSuffix(vis.BaseURL, "/"), owner, repo, url.QueryEscape(ref))
+}
+
+// GetTree lists all files of a ref as a recursive tree.
+func (vis *Visitor) GetTree(u string) (*github.Tree, bool, error) {
+	base, err := url.Parse(u)
+	if err != nil {
+		return nil, true, fmt.Errorf("error on parsing tree URL: %s", err)
+	}
+	ref := base.Query().Get("at")
+	repository := base.Scheme + "://" + base.Host + strings.TrimSuffix(base.EscapedPath(), "/files")
+
+	t := &github.Tree{URL: u}
+	for start := 0; ; {
+		q := base.Query()
+		q.Set("start", fmt.Sprint(start))
+		q.Set("limit", fmt.Sprint(pageSize))
+		page := new(files)
+		err := rest.GetJSON(vis.Client, base.Scheme+"://"+base.Host+base.EscapedPath()+"?"+q.Encode(), vis.header(), page)
+		if err != nil {
+			return nil, true, err
+		}
+
+		for _, path := range page.Values {
+			// Sizes are not listed in Bitbucket Server, so they are left unknown, and large blobs are
+			// told by streaming them.
+			raw := repository + "/raw/" + escapePath(path)
+			if ref != "" {
+				raw += "?at=" + url.QueryEscape(ref)
+			}
+			t.Tree = append(t.Tree, &github.Submodule{
+				Path: path,
+				Mode: github.ModeFile,
+				Type: "blob",
+				URL:  raw,
+			})
+		}
+		if page.IsLastPage || len(page.Values) == 0 {
+			break
+		}
+		start = page.NextPageStart
+	}
+
+	return t, true, nil
+}
+
+// GetBlob gets raw content of a file.
+func (vis *Visitor) GetBlob(u string) ([]byte, error) {
+	body, err := rest.Get(vis.Client, u, vis.header())
+	if err != nil {
+		return nil, fmt.Errorf("error on requesting a blob: %s", err)
+	}
+	return body, nil
+}
+
+// OpenBlob requests raw content of a file, and returns the body to be streamed.
+func (vis *Visitor) OpenBlob(u string) (io.ReadCloser, error) {
+	body, err := rest.Open(vis.Client, u, vis.header())
+	if err != nil {
+		return nil, fmt.Errorf("error on requesting a blob: %s", err)
+	}
+	return body, nil
+}
+
+// escapePath escapes each segment of a path.
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// header returns the header authorizing the requests, which is empty if there is no token.
+func (vis *Visitor) header() http.Header {
+	header := http.Header{}
+	if vis.Token != "" {
+		header.Set("Authorization", "Bearer "+vis.Token)
+	}
+	return header
+}
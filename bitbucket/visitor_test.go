@@ -0,0 +1,71 @@
+package bitbucket
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTreeAndBlob(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Bearer secret-token" {
+			t.Errorf("%s requested with authorization %q", r.URL, auth)
+		}
+		if at := r.URL.Query().Get("at"); at != "refs/heads/main" {
+			t.Errorf("%s requested at %q, want refs/heads/main", r.URL, at)
+		}
+		switch r.URL.EscapedPath() {
+		case "/rest/api/1.0/projects/PRJ/repos/name/files":
+			// Files are served a page at a time.
+			switch r.URL.Query().Get("start") {
+			case "0":
+				fmt.Fprint(w, `{"values": ["README.md"], "isLastPage": false, "nextPageStart": 1}`)
+			case "1":
+				fmt.Fprint(w, `{"values": ["docs/a b.md"], "isLastPage": true}`)
+			default:
+				t.Errorf("unexpected page of %s", r.URL)
+			}
+		case "/rest/api/1.0/projects/PRJ/repos/name/raw/docs/a%20b.md":
+			fmt.Fprint(w, "# A b\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	vis, err := NewVisitor("secret-token", server.URL+"/rest/api/1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree, recursive, err := vis.GetTree(vis.GetTreeURL("PRJ", "name", "refs/heads/main"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !recursive || len(tree.Tree) != 2 {
+		t.Fatalf("tree = %+v, recursive = %v, want 2 entries recursively", tree, recursive)
+	}
+	blob := tree.Tree[1]
+	if blob.Path != "docs/a b.md" || blob.Type != "blob" || blob.Size != nil {
+		t.Errorf("blob entry = %+v, want docs/a b.md of an unknown size", blob)
+	}
+
+	data, err := vis.GetBlob(blob.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "# A b\n" {
+		t.Errorf("blob = %q", data)
+	}
+	body, err := vis.OpenBlob(blob.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = ioutil.ReadAll(body)
+	body.Close()
+	if err != nil || string(data) != "# A b\n" {
+		t.Errorf("streamed blob = %q, %v", data, err)
+	}
+}
@@ -1,29 +1,596 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/huangjiuyuan/typospider/bitbucket"
+	"github.com/huangjiuyuan/typospider/crawl"
+	"github.com/huangjiuyuan/typospider/gitea"
 	"github.com/huangjiuyuan/typospider/github"
+	"github.com/huangjiuyuan/typospider/gitlab"
 	"github.com/huangjiuyuan/typospider/language"
 	"github.com/huangjiuyuan/typospider/process"
+	"github.com/huangjiuyuan/typospider/spell"
 )
 
 func main() {
-	vis, err := github.NewVisitor(true, "68999f8a97ee7b912fa2b55da098d9a9021c5e04")
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "triage":
+			triage(os.Args[2:])
+			return
+		case "fix":
+			fix(os.Args[2:])
+			return
+		case "pr":
+			pr(os.Args[2:])
+			return
+		}
+	}
+
+	scan(os.Args[1:])
+}
+
+// scan processes a GitHub project and indexes the typos found.
+func scan(args []string) {
+	fs := flag.NewFlagSet("scan", flag.ExitOnError)
+	backend := fs.String("checker", "languagetool", "checker of the texts, languagetool or hunspell")
+	dictionary := fs.String("dictionary", "/usr/share/hunspell/en_US", "path of the Hunspell dictionary without extension")
+	servers := fs.String("languagetool", "localhost:6066", "comma separated addresses of LanguageTool servers")
+	timeout := fs.Duration("timeout", language.DefaultTimeout, "timeout of a LanguageTool request")
+	retries := fs.Int("retries", language.DefaultRetries, "retries of a failed LanguageTool request")
+	lang := fs.String("language", "en", "language code of the texts, or auto for detecting the language of each comment")
+	pathLanguages := fs.String("path-language", "", "comma separated languages of paths, like docs/zh=zh-CN")
+	preferredVariants := fs.String("preferred-variants", "", "comma separated language variants preferred by auto detection, like en-US")
+	level := fs.String("level", "", "level of the checks, default or picky")
+	username := fs.String("username", "", "username of the LanguageTool Premium API")
+	apiKey := fs.String("api-key", os.Getenv("LANGUAGETOOL_API_KEY"), "API key of the LanguageTool Premium API")
+	dicts := fs.String("dicts", "", "comma separated user dictionaries of the LanguageTool Premium API")
+	disabledRules := fs.String("disabled-rules", "", "comma separated IDs of LanguageTool rules to disable")
+	annotate := fs.Bool("annotate", true, "skip code spans, identifiers and URLs in comments")
+	docRules := fs.Bool("doc-rules", false, "check that doc comments start with the declared names")
+	strs := fs.Bool("strings", false, "check string literals of error messages, logs and command help text")
+	identifiers := fs.Bool("identifiers", false, "check the words of declared identifiers with the Hunspell dictionary")
+	messages := fs.String("messages", "", "comma separated messages to check instead of files, commits or pulls")
+	gitDir := fs.String("git", "", "local git repository to read commit messages from instead of GitHub")
+	owner := fs.String("owner", "kubernetes", "owner of the repository")
+	repo := fs.String("repo", "kubernetes", "name of the repository")
+	limit := fs.Int("limit", 1000, "maximum number of commits or pull requests to check")
+	org := fs.String("org", "", "organization whose repositories are checked")
+	repoList := fs.String("repos", "", "file listing the repositories to check, one owner/name per line")
+	forks := fs.Bool("forks", false, "check forks of the organization or the list")
+	archived := fs.Bool("archived", false, "check archived repositories of the organization or the list")
+	repoLanguage := fs.String("repo-language", "", "primary language of the repositories to check, like Go")
+	topics := fs.String("topics", "", "comma separated topics, one of which the repositories to check must have")
+	crawlRepo := fs.String("crawl", "", "URL of a repository whose web UI is crawled instead of using the API")
+	ref := fs.String("ref", "master", "branch or tag to crawl or scan")
+	layout := fs.String("layout", "github", "layout of the crawled web UI, github, gitlab or gitea")
+	maxVisits := fs.Int("max-visits", 0, "maximum number of pages crawled, or 0 for no limit")
+	crawlDelay := fs.Duration("crawl-delay", time.Second, "delay between requests to the same host when crawling")
+	site := fs.String("site", "", "URL of a project website or rendered documentation to crawl and check")
+	siteScope := fs.String("site-scope", "", "URL prefix of the pages to check, defaults to the site URL")
+	provider := fs.String("provider", "github", "hosting service of the repository, github, gitlab, gitea or bitbucket")
+	providerURL := fs.String("provider-url", "", "base URL of the API of the hosting service, like https://gitlab.example.com/api/v4")
+	providerToken := fs.String("provider-token", "", "access token of the hosting service other than GitHub")
+	maxBlobSize := fs.Int("max-blob-size", process.DefaultMaxBlobSize, "maximum size in bytes of blobs checked, or 0 for no limit")
+	maxQueued := fs.Int64("max-queued-bytes", process.DefaultMaxQueuedBytes, "maximum bytes of blobs waiting to be checked with their content, or 0 for no limit")
+	storeContent := fs.Bool("store-content", true, "store the content of files with typos in Elasticsearch, which is needed by fix and pr")
+	fileIndex := fs.String("file-index", process.DefaultFileIndex, "index of the files")
+	submodules := fs.Bool("submodules", false, "follow submodules hosted by GitHub into their repositories at the pinned commits")
+	archive := fs.Bool("archive", false, "download GitHub repositories as tarballs instead of walking their trees and blobs")
+	blobBatch := fs.Int("blob-batch", process.DefaultBlobBatchSize, "number of GitHub blobs fetched per GraphQL query, or 0 for fetching blobs one by one")
+	gh := addGitHubFlags(fs)
+	fs.Parse(args)
+
+	vis, err := gh.visitor(true)
 	if err != nil {
 		fmt.Println(err)
+		os.Exit(1)
 	}
 
-	lt, err := language.NewLanguageTool("localhost", "6066")
+	var checker language.Checker
+	switch *backend {
+	case "languagetool":
+		lt, err := language.NewLanguageToolCluster(strings.Split(*servers, ","))
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		lt.Client = language.NewClient(*timeout)
+		lt.Retries = *retries
+		checker = lt
+	case "hunspell":
+		hs, err := spell.NewChecker(*dictionary)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		checker = hs
+	default:
+		fmt.Printf("unknown checker %s\n", *backend)
+		os.Exit(2)
+	}
+
+	proc, err := process.NewProcesser(1000, vis, checker, 10, true)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if *provider != "github" {
+		proc.Provider, err = newProvider(*provider, *providerURL, *providerToken, vis.Client)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(2)
+		}
+	}
+	proc.Options = language.CheckOptions{
+		Language:          *lang,
+		PreferredVariants: *preferredVariants,
+		Level:             *level,
+		Username:          *username,
+		APIKey:            *apiKey,
+		Dicts:             *dicts,
+		DisabledRules:     *disabledRules,
+	}
+	proc.Annotate = *annotate
+	proc.Archives = *archive
+	proc.Submodules = *submodules
+	proc.MaxBlobSize = *maxBlobSize
+	proc.MaxQueuedBytes = *maxQueued
+	proc.StoreContent = *storeContent
+	proc.FileIndex = *fileIndex
+	proc.BlobBatchSize = *blobBatch
+	if vis.Auth == nil {
+		// GraphQL API is not available to anonymous requests.
+		proc.BlobBatchSize = 0
+	}
+	proc.Strings = *strs
+	proc.DocRules = *docRules
+	proc.LanguageRules, err = process.ParseLanguageRules(*pathLanguages)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if *identifiers {
+		hs, err := spell.NewChecker(*dictionary)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		proc.Dictionary = hs.Dictionary
+	}
+	err = proc.CheckLanguages()
 	if err != nil {
 		fmt.Println(err)
+		os.Exit(1)
 	}
 
-	proc, err := process.NewProcesser(1000, vis, lt, 10, true)
+	if *messages != "" {
+		scanMessages(proc, strings.Split(*messages, ","), *gitDir, *owner, *repo, *limit)
+		return
+	}
+
+	if *site != "" {
+		err = proc.ProcessSite(&crawl.Site{
+			Root:      *site,
+			Scope:     *siteScope,
+			MaxVisits: *maxVisits,
+			Delay:     *crawlDelay,
+		}, "page", "page-typo")
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *crawlRepo != "" {
+		l, ok := crawl.Layouts[*layout]
+		if !ok {
+			fmt.Printf("unknown layout %s\n", *layout)
+			os.Exit(2)
+		}
+		source := &crawl.Source{
+			Repo:      *crawlRepo,
+			Ref:       *ref,
+			Layout:    l,
+			Accept:    proc.Accepts,
+			Found:     proc.Enqueue,
+			MaxVisits: *maxVisits,
+			Delay:     *crawlDelay,
+		}
+		go func() {
+			err := crawl.Crawl(source)
+			if err != nil {
+				fmt.Println(err)
+			}
+			proc.Finish()
+		}()
+		proc.ProcessBlob()
+		printSkipped(proc)
+		return
+	}
+
+	if *org != "" || *repoList != "" {
+		filter := &process.RepositoryFilter{
+			Forks:    *forks,
+			Archived: *archived,
+			Language: *repoLanguage,
+		}
+		if *topics != "" {
+			filter.Topics = strings.Split(*topics, ",")
+		}
+		scanRepositories(proc, *org, *repoList, filter)
+		return
+	}
+
+	if *archive && *provider == "github" {
+		go proc.ProcessArchive(*owner, *repo, *ref)
+	} else {
+		go proc.ProcessTree(proc.Provider.GetTreeURL(*owner, *repo, *ref))
+	}
+	proc.ProcessBlob()
+	printSkipped(proc)
+}
+
+// newProvider returns the provider of a hosting service other than GitHub, whose API is served at the
+// base URL. The base URL of GitLab defaults to gitlab.com. The requests share the HTTP client, which
+// is configured by the proxy and CA flags.
+func newProvider(name string, baseURL string, token string, client *http.Client) (process.Provider, error) {
+	switch name {
+	case "gitlab":
+		vis, err := gitlab.NewVisitor(true, token)
+		if err != nil {
+			return nil, err
+		}
+		if baseURL != "" {
+			vis.BaseURL = baseURL
+		}
+		vis.Client = client
+		return vis, nil
+	case "gitea":
+		vis, err := gitea.NewVisitor(true, token, baseURL)
+		if err != nil {
+			return nil, err
+		}
+		vis.Client = client
+		return vis, nil
+	case "bitbucket":
+		vis, err := bitbucket.NewVisitor(token, baseURL)
+		if err != nil {
+			return nil, err
+		}
+		vis.Client = client
+		return vis, nil
+	default:
+		return nil, fmt.Errorf("unknown provider %s", name)
+	}
+}
+
+// scanRepositories checks the repositories of an organization or a list, and prints the results of
+// each repository.
+func scanRepositories(proc *process.Processer, org string, list string, filter *process.RepositoryFilter) {
+	var repos []*github.Repository
+	var err error
+	if org != "" {
+		repos, err = process.OrganizationRepositories(proc.Visitor, org, filter)
+	} else {
+		repos, err = process.ListedRepositories(proc.Visitor, list, filter)
+	}
 	if err != nil {
 		fmt.Println(err)
+		os.Exit(1)
 	}
 
-	go proc.ProcessTree("https://api.github.com/repos/kubernetes/kubernetes/git/trees/a740c006931a59cc99cfbb103208758bbc42baf0")
+	go proc.ProcessRepositories(repos)
 	proc.ProcessBlob()
+
+	printSkipped(proc)
+	for _, summary := range proc.Summaries() {
+		fmt.Printf("%s: %d typos in %d of %d files, %d skipped\n", summary.Repo, summary.Typos, summary.FilesWithTypos, summary.Files, summary.Skipped)
+	}
+}
+
+// printSkipped prints the files which are not checked with the reasons.
+func printSkipped(proc *process.Processer) {
+	for _, f := range proc.Skipped() {
+		name := f.Path
+		if f.Repo != "" {
+			name = f.Repo + ":" + f.Path
+		}
+		fmt.Printf("Skipped %s: %s\n", name, f.Reason)
+	}
+}
+
+// scanMessages checks commit messages and pull request titles and descriptions, and indexes the typos
+// found to the message-typo index.
+func scanMessages(proc *process.Processer, kinds []string, gitDir string, owner string, repo string, limit int) {
+	msgs := []*process.Message{}
+	for _, kind := range kinds {
+		var list []*process.Message
+		var err error
+		switch {
+		case kind == "commits" && gitDir != "":
+			list, err = process.LocalCommits(gitDir, limit)
+		case kind == "commits":
+			list, err = process.GitHubCommits(proc.Visitor, owner, repo, limit)
+		case kind == "pulls":
+			list, err = process.GitHubPullRequests(proc.Visitor, owner, repo, limit)
+		default:
+			fmt.Printf("unknown messages %s\n", kind)
+			os.Exit(2)
+		}
+		if err != nil {
+			fmt.Println(err)
+		}
+		msgs = append(msgs, list...)
+	}
+
+	err := proc.ProcessMessages(msgs, "message", "message-typo")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+// triage marks a typo as accepted or ignored. Ignored typos are suppressed in subsequent scans.
+func triage(args []string) {
+	fs := flag.NewFlagSet("triage", flag.ExitOnError)
+	index := fs.String("index", "typo", "index of the typos")
+	reason := fs.String("reason", "", "reason of the decision")
+	author := fs.String("author", os.Getenv("USER"), "author of the decision")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: typospider triage accept|ignore <id> [flags]\n")
+		fs.PrintDefaults()
+	}
+	if len(args) < 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	var status string
+	switch args[0] {
+	case "accept":
+		status = process.TypoAccepted
+	case "ignore":
+		status = process.TypoIgnored
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+	id := args[1]
+	fs.Parse(args[2:])
+
+	es, err := process.InitClient("http", "localhost", "9200", false)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	typo, err := es.TriageTypo(*index, id, status, *reason, *author)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	fmt.Printf("Typo %s marked as %s\n", typo.SHA, typo.Status)
+}
+
+// fix generates a patch fixing the typos of a file with their suggested replacements.
+func fix(args []string) {
+	fs := flag.NewFlagSet("fix", flag.ExitOnError)
+	fileIndex := fs.String("file-index", process.DefaultFileIndex, "index of the files")
+	typoIndex := fs.String("typo-index", "typo", "index of the typos")
+	choice := fs.Int("choice", 0, "index of the replacement applied to each typo")
+	dryRun := fs.Bool("dry-run", false, "print the patch instead of writing it")
+	out := fs.String("out", "", "path of the patch, defaults to <id>.patch")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: typospider fix <id> [flags]\n")
+		fs.PrintDefaults()
+	}
+	if len(args) < 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	id := args[0]
+	fs.Parse(args[1:])
+
+	es, err := process.InitClient("http", "localhost", "9200", false)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	file, err := es.LoadFile(*fileIndex, id)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	err = file.Editable()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	edits, err := es.LoadEdits(*typoIndex, file, *choice)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	data, shifted := file.Source(edits)
+	patch, err := process.Patch(file.Path, data, shifted)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if patch == "" {
+		fmt.Printf("No fix for %s\n", file.Path)
+		return
+	}
+
+	if *dryRun {
+		fmt.Print(patch)
+		return
+	}
+	if *out == "" {
+		*out = id + ".patch"
+	}
+	err = ioutil.WriteFile(*out, []byte(patch), 0644)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	fmt.Printf("Patch of %d fixes written to %s\n", len(edits), *out)
+}
+
+// pr opens pull requests upstream with the fixes of accepted typos, one per directory.
+func pr(args []string) {
+	fs := flag.NewFlagSet("pr", flag.ExitOnError)
+	owner := fs.String("owner", "kubernetes", "owner of the repository")
+	repo := fs.String("repo", "kubernetes", "name of the repository")
+	fileIndex := fs.String("file-index", process.DefaultFileIndex, "index of the files")
+	typoIndex := fs.String("typo-index", "typo", "index of the typos")
+	dryRun := fs.Bool("dry-run", false, "print the pull requests instead of opening them")
+	gh := addGitHubFlags(fs)
+	fs.Parse(args)
+
+	es, err := process.InitClient("http", "localhost", "9200", false)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	batches, err := es.LoadBatches(*fileIndex, *typoIndex, *owner+"/"+*repo)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if *dryRun {
+		for _, batch := range batches {
+			fmt.Printf("%s\n\n%s\n", batch.Title(), batch.Description())
+		}
+		return
+	}
+
+	vis, err := gh.visitor(false)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if vis.Auth == nil {
+		fmt.Println("a GitHub token or app is required to open pull requests")
+		os.Exit(2)
+	}
+
+	prs, err := process.OpenPullRequests(vis, *owner, *repo, batches)
+	for _, pr := range prs {
+		fmt.Printf("Pull request #%d opened: %s\n", pr.Number, pr.HTMLURL)
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+// githubFlags are the flags of the endpoint of GitHub API and the authentication to it.
+type githubFlags struct {
+	api            *string
+	uploadURL      *string
+	caFile         *string
+	proxy          *string
+	token          *string
+	tokenFile      *string
+	gitCredential  *bool
+	appID          *int64
+	installationID *int64
+	appKey         *string
+}
+
+// addGitHubFlags adds the flags of GitHub API to the flag set.
+func addGitHubFlags(fs *flag.FlagSet) *githubFlags {
+	return &githubFlags{
+		api:            fs.String("api", github.DefaultBaseURL, "base URL of GitHub API, like https://github.example.com/api/v3 for GitHub Enterprise Server"),
+		uploadURL:      fs.String("upload-url", "", "upload URL of GitHub API, derived from the base URL if empty"),
+		caFile:         fs.String("ca-file", "", "PEM bundle of certificate authorities trusted besides the system ones"),
+		proxy:          fs.String("proxy", "", "URL of the proxy of GitHub API, defaults to $HTTPS_PROXY"),
+		token:          fs.String("token", "", "GitHub token, defaults to $GITHUB_TOKEN or $GH_TOKEN"),
+		tokenFile:      fs.String("token-file", "", "file containing the GitHub token"),
+		gitCredential:  fs.Bool("git-credential", false, "ask git credential helpers for the GitHub token if no token is given"),
+		appID:          fs.Int64("app-id", 0, "ID of the GitHub App to authenticate as"),
+		installationID: fs.Int64("installation-id", 0, "ID of the installation of the GitHub App"),
+		appKey:         fs.String("app-key", "", "PEM file of the private key of the GitHub App"),
+	}
+}
+
+// visitor returns a visitor of GitHub API configured by the flags.
+func (f *githubFlags) visitor(recursive bool) (*github.Visitor, error) {
+	vis, err := github.NewVisitor(recursive, "")
+	if err != nil {
+		return nil, err
+	}
+	vis.BaseURL, vis.UploadURL, err = github.EnterpriseURLs(*f.api, *f.uploadURL)
+	if err != nil {
+		return nil, err
+	}
+	vis.Client, err = github.NewClient(github.ClientOptions{
+		CAFile: *f.caFile,
+		Proxy:  *f.proxy,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	vis.Auth, err = f.source(vis.BaseURL, vis.Client)
+	if err != nil {
+		return nil, err
+	}
+	return vis, nil
+}
+
+// source returns the source of tokens of GitHub API at the base URL, in the order of the GitHub App,
+// the token flag, the token file, the environment and git credential helpers. Requests are anonymous
+// if it returns nil.
+func (f *githubFlags) source(baseURL string, client *http.Client) (github.TokenSource, error) {
+	if *f.appID != 0 {
+		app, err := github.NewAppTokenSource(*f.appID, *f.installationID, *f.appKey)
+		if err != nil {
+			return nil, err
+		}
+		app.BaseURL = baseURL
+		app.Client = client
+		return app, nil
+	}
+
+	token := github.StaticToken(*f.token)
+	if token == "" && *f.tokenFile != "" {
+		t, err := github.TokenFromFile(*f.tokenFile)
+		if err != nil {
+			return nil, err
+		}
+		token = t
+	}
+	if token == "" {
+		token = github.TokenFromEnv("GITHUB_TOKEN", "GH_TOKEN")
+	}
+	if token == "" && *f.gitCredential {
+		t, err := github.TokenFromGitCredential(github.CredentialHost(baseURL))
+		if err != nil {
+			return nil, err
+		}
+		token = t
+	}
+	if token == "" {
+		fmt.Printf("[Warning] No GitHub token found, requesting anonymously\n")
+		return nil, nil
+	}
+
+	github.AddSecret(string(token))
+	return token, nil
 }
@@ -1,41 +1,215 @@
 package crawl
 
 import (
+	"fmt"
+	"io/ioutil"
 	"net/http"
-	"regexp"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/PuerkitoBio/gocrawl"
 	"github.com/PuerkitoBio/goquery"
+	"github.com/huangjiuyuan/typospider/github"
 )
 
-// Only enqueue the root and paths matching expression.
-var exp = regexp.MustCompile(`http://github\.com/kubernetes/kubernetes/tree/master(/[a-g].*)?$`)
+// Layout describes the URLs of the web UI of a git hosting service.
+type Layout struct {
+	// Path segment of tree pages between the repository and the ref, like "tree".
+	Tree string
+	// Path segment of file pages between the repository and the ref, like "blob".
+	Blob string
+	// Raw returns the URL of the raw content of a file in a repository at a ref.
+	Raw func(repo *url.URL, ref string, path string) string
+}
+
+// Layouts of well-known git web UIs.
+var (
+	GitHubLayout = Layout{
+		Tree: "tree",
+		Blob: "blob",
+		Raw: func(repo *url.URL, ref string, path string) string {
+			return "https://raw.githubusercontent.com" + repo.Path + "/" + ref + "/" + path
+		},
+	}
+	GitLabLayout = Layout{
+		Tree: "-/tree",
+		Blob: "-/blob",
+		Raw: func(repo *url.URL, ref string, path string) string {
+			return repo.String() + "/-/raw/" + ref + "/" + path
+		},
+	}
+	GiteaLayout = Layout{
+		Tree: "src/branch",
+		Blob: "src/branch",
+		Raw: func(repo *url.URL, ref string, path string) string {
+			return repo.String() + "/raw/branch/" + ref + "/" + path
+		},
+	}
+)
+
+// Layouts are the well-known layouts keyed by their names.
+var Layouts = map[string]Layout{
+	"github": GitHubLayout,
+	"gitlab": GitLabLayout,
+	"gitea":  GiteaLayout,
+}
 
-// Extender creates the Extender implementation.
+// Source crawls the web UI of a git repository without using its API, which is useful when the API
+// quota is exhausted. It follows the tree pages from the root of a ref, and fetches the raw content of
+// the files it accepts.
+type Source struct {
+	// URL of the repository, like "https://github.com/kubernetes/kubernetes".
+	Repo string
+	// Ref to crawl, like "master". Refs containing slashes are not supported.
+	Ref string
+	// Layout of the web UI.
+	Layout Layout
+	// Accept returns whether a file is fetched, by its path relative to the repository root.
+	Accept func(path string) bool
+	// Found is called with each file fetched, whose data is filled.
+	Found func(blob *github.Blob)
+	// Maximum number of pages visited, or 0 for no limit.
+	MaxVisits int
+	// Delay between requests to the same host.
+	Delay time.Duration
+}
+
+// Extender implements the gocrawl Extender of a Source.
 type Extender struct {
 	gocrawl.DefaultExtender
+
+	// Source to crawl.
+	source *Source
+	// URL of the repository.
+	repo *url.URL
+	// Name of the repository, like "kubernetes/kubernetes".
+	name string
+
+	// Lock of the raw files.
+	mu sync.Mutex
+	// Paths of the files keyed by the paths of their raw URLs.
+	raws map[string]string
+}
+
+// NewExtender returns an Extender of the source with an error if necessary.
+func NewExtender(source *Source) (*Extender, error) {
+	repo, err := url.Parse(strings.TrimSuffix(source.Repo, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("error on parsing repository URL: %s", err)
+	}
+	if source.Ref == "" || strings.Contains(source.Ref, "/") {
+		return nil, fmt.Errorf("invalid ref %q", source.Ref)
+	}
+	if source.Layout.Raw == nil {
+		return nil, fmt.Errorf("missing raw URLs of the layout")
+	}
+
+	return &Extender{
+		source: source,
+		repo:   repo,
+		name:   strings.Trim(repo.Path, "/"),
+		raws:   make(map[string]string),
+	}, nil
 }
 
-// Visit overrides the default Visit function.
+// Root returns the URL of the root tree page of the ref.
+func (ext *Extender) Root() string {
+	return ext.repo.String() + "/" + ext.source.Layout.Tree + "/" + ext.source.Ref
+}
+
+// Visit overrides the default Visit function. It feeds the raw files found to the source, and
+// harvests the links to the tree pages and the raw files in the repository.
 func (ext *Extender) Visit(ctx *gocrawl.URLContext, res *http.Response, doc *goquery.Document) (interface{}, bool) {
-	return nil, true
+	ext.mu.Lock()
+	path, raw := ext.raws[ctx.URL().Path]
+	ext.mu.Unlock()
+	if raw {
+		data, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			fmt.Printf("[Error] Read file %s failed: %s\n", path, err)
+			return nil, false
+		}
+		ext.source.Found(&github.Blob{
+			Path: path,
+			Size: len(data),
+			SHA:  github.BlobSHA(data),
+			URL:  ctx.URL().String(),
+			Repo: ext.name,
+			Data: &data,
+		})
+		return nil, false
+	}
+	if doc == nil {
+		return nil, false
+	}
+
+	links := []string{}
+	doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		link, err := doc.Url.Parse(href)
+		if err != nil {
+			return
+		}
+		link.RawQuery, link.Fragment = "", ""
+
+		if path, ok := ext.pathOf(link, ext.source.Layout.Blob); ok && path != "" && ext.source.Accept(path) {
+			raw := ext.source.Layout.Raw(ext.repo, ext.source.Ref, path)
+			u, err := url.Parse(raw)
+			if err != nil {
+				return
+			}
+			ext.mu.Lock()
+			ext.raws[u.Path] = path
+			ext.mu.Unlock()
+			links = append(links, raw)
+			return
+		}
+		if _, ok := ext.pathOf(link, ext.source.Layout.Tree); ok {
+			links = append(links, link.String())
+		}
+	})
+
+	return links, false
 }
 
-// Filter overrides the default Filter function.
+// Filter overrides the default Filter function, so that each page is visited once.
 func (ext *Extender) Filter(ctx *gocrawl.URLContext, isVisited bool) bool {
-	return !isVisited && exp.MatchString(ctx.NormalizedURL().String())
+	return !isVisited
 }
 
-func Crawl() {
+// pathOf returns the path of a file or a directory relative to the repository root if the link is a
+// page of the ref of the given kind, like "tree" or "blob".
+func (ext *Extender) pathOf(link *url.URL, kind string) (string, bool) {
+	prefix := ext.repo.Path + "/" + kind + "/" + ext.source.Ref
+	if link.Host != ext.repo.Host || !strings.HasPrefix(link.Path, prefix) {
+		return "", false
+	}
+	rest := link.Path[len(prefix):]
+	if rest != "" && !strings.HasPrefix(rest, "/") {
+		return "", false
+	}
+	return strings.TrimPrefix(rest, "/"), true
+}
+
+// Crawl crawls the source until no more page is found or the maximum number of visits is reached.
+func Crawl(source *Source) error {
+	ext, err := NewExtender(source)
+	if err != nil {
+		return err
+	}
+
 	// Set custom options.
-	opts := gocrawl.NewOptions(new(Extender))
+	opts := gocrawl.NewOptions(ext)
 	opts.RobotUserAgent = "CCBot"
 	opts.UserAgent = "TypoSpider"
-	opts.CrawlDelay = 1 * time.Second
-	opts.LogFlags = gocrawl.LogAll
-	opts.MaxVisits = 5
+	opts.CrawlDelay = source.Delay
+	opts.LogFlags = gocrawl.LogError
+	opts.MaxVisits = source.MaxVisits
+	// Raw files may be served by another host, like raw.githubusercontent.com.
+	opts.SameHostOnly = false
 
 	c := gocrawl.NewCrawlerWithOptions(opts)
-	c.Run("https://github.com/kubernetes/kubernetes/tree/master")
+	return c.Run(ext.Root())
 }
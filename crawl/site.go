@@ -0,0 +1,280 @@
+package crawl
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/gocrawl"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/PuerkitoBio/purell"
+	"golang.org/x/net/html"
+)
+
+// Elements whose text is not prose, like code, scripts and navigation.
+var skippedElements = map[string]bool{
+	"aside":    true,
+	"button":   true,
+	"footer":   true,
+	"form":     true,
+	"header":   true,
+	"nav":      true,
+	"noscript": true,
+	"pre":      true,
+	"script":   true,
+	"select":   true,
+	"style":    true,
+	"svg":      true,
+	"template": true,
+	"textarea": true,
+}
+
+// Word replacing code in the text of blocks, which is read as a noun and keeps sentences intact.
+const codePlaceholder = "code"
+
+// Inline elements whose text is code, which are replaced by codePlaceholder in the text of blocks.
+var codeElements = map[string]bool{
+	"code": true,
+	"kbd":  true,
+	"samp": true,
+	"tt":   true,
+	"var":  true,
+}
+
+// Elements containing blocks of prose, and the kinds of their blocks.
+var blockElements = map[string]string{
+	"h1":         KindHeading,
+	"h2":         KindHeading,
+	"h3":         KindHeading,
+	"h4":         KindHeading,
+	"h5":         KindHeading,
+	"h6":         KindHeading,
+	"p":          KindParagraph,
+	"blockquote": KindParagraph,
+	"figcaption": KindParagraph,
+	"td":         KindParagraph,
+	"th":         KindParagraph,
+	"li":         KindListItem,
+	"dt":         KindListItem,
+	"dd":         KindListItem,
+}
+
+// Kinds of blocks of a page, which are the same as the kinds of the tokens of documents in process.
+const (
+	// KindHeading is the kind of headings.
+	KindHeading = "heading"
+	// KindParagraph is the kind of paragraphs and table cells.
+	KindParagraph = "paragraph"
+	// KindListItem is the kind of list items and definitions.
+	KindListItem = "list item"
+)
+
+// Extensions of links which are not pages, like images and archives.
+var assetExts = map[string]bool{
+	".css": true, ".js": true, ".json": true, ".xml": true, ".txt": true,
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".ico": true, ".webp": true,
+	".pdf": true, ".zip": true, ".gz": true, ".tgz": true, ".tar": true,
+	".mp4": true, ".webm": true, ".woff": true, ".woff2": true, ".ttf": true,
+}
+
+// Site crawls a project website or its rendered documentation, and extracts the visible prose of
+// each page. Pages out of the scope are not visited.
+type Site struct {
+	// URL where the crawl starts, like "https://kubernetes.io/docs/".
+	Root string
+	// URL prefix of the pages to visit, which defaults to the root.
+	Scope string
+	// Found is called with each page visited.
+	Found func(page *Page)
+	// Maximum number of pages visited, or 0 for no limit.
+	MaxVisits int
+	// Delay between requests.
+	Delay time.Duration
+}
+
+// Page is a page of a site.
+type Page struct {
+	// URL of the page.
+	URL string
+	// Title of the page.
+	Title string
+	// Blocks of prose in the page, in document order.
+	Blocks []*Block
+}
+
+// Block is a block of prose in a page, like a heading or a paragraph.
+type Block struct {
+	// CSS selector of the element of the block, like "#install > p:nth-child(2)".
+	Selector string
+	// Kind of the block, like KindHeading.
+	Kind string
+	// Visible text of the block, where whitespace is collapsed and code is replaced by a placeholder.
+	Text string
+}
+
+// SiteExtender implements the gocrawl Extender of a Site.
+type SiteExtender struct {
+	gocrawl.DefaultExtender
+
+	// Site to crawl.
+	site *Site
+	// URL prefix of the pages to visit.
+	scope *url.URL
+}
+
+// NewSiteExtender returns a SiteExtender of the site with an error if necessary.
+func NewSiteExtender(site *Site) (*SiteExtender, error) {
+	scope := site.Scope
+	if scope == "" {
+		scope = site.Root
+	}
+	u, err := url.Parse(scope)
+	if err != nil {
+		return nil, fmt.Errorf("error on parsing scope: %s", err)
+	}
+	// Normalize the scope in the same way as the URLs to visit, which drops trailing slashes.
+	u, err = url.Parse(purell.NormalizeURL(u, gocrawl.DefaultNormalizationFlags))
+	if err != nil {
+		return nil, fmt.Errorf("error on parsing scope: %s", err)
+	}
+
+	return &SiteExtender{
+		site:  site,
+		scope: u,
+	}, nil
+}
+
+// Visit overrides the default Visit function. It extracts the prose of HTML pages, and lets gocrawl
+// harvest the links.
+func (ext *SiteExtender) Visit(ctx *gocrawl.URLContext, res *http.Response, doc *goquery.Document) (interface{}, bool) {
+	if doc == nil || !strings.Contains(res.Header.Get("Content-Type"), "html") {
+		return nil, false
+	}
+
+	ext.site.Found(ExtractPage(ctx.URL().String(), doc))
+	return nil, true
+}
+
+// Filter overrides the default Filter function, so that each page in the scope is visited once.
+func (ext *SiteExtender) Filter(ctx *gocrawl.URLContext, isVisited bool) bool {
+	u := ctx.NormalizedURL()
+	prefix := strings.TrimSuffix(ext.scope.Path, "/")
+	if isVisited || u.Host != ext.scope.Host || (u.Path != prefix && !strings.HasPrefix(u.Path, prefix+"/")) {
+		return false
+	}
+	return !assetExts[strings.ToLower(path.Ext(u.Path))]
+}
+
+// ExtractPage extracts the blocks of prose of an HTML document.
+func ExtractPage(u string, doc *goquery.Document) *Page {
+	page := &Page{
+		URL:   u,
+		Title: strings.TrimSpace(doc.Find("title").First().Text()),
+	}
+
+	for _, n := range doc.Find("body").Nodes {
+		walkBlocks(n, page)
+	}
+	return page
+}
+
+// walkBlocks adds the blocks under the node to the page.
+func walkBlocks(n *html.Node, page *Page) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || skippedElements[c.Data] {
+			continue
+		}
+		if kind, ok := blockElements[c.Data]; ok {
+			text := strings.Join(strings.Fields(blockText(c)), " ")
+			if text != "" {
+				page.Blocks = append(page.Blocks, &Block{
+					Selector: selectorOf(c),
+					Kind:     kind,
+					Text:     text,
+				})
+			}
+		}
+		// Blocks may be nested, like a paragraph in a list item.
+		walkBlocks(c, page)
+	}
+}
+
+// blockText returns the text of a block without the text of its nested blocks and skipped elements.
+func blockText(n *html.Node) string {
+	var buf bytes.Buffer
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case c.Type == html.TextNode:
+			buf.WriteString(c.Data)
+		case c.Type != html.ElementNode || skippedElements[c.Data]:
+		case blockElements[c.Data] != "":
+			// Nested blocks are separate blocks, but keep the words apart.
+			buf.WriteString(" ")
+		case codeElements[c.Data]:
+			buf.WriteString(codePlaceholder)
+		case c.Data == "br":
+			buf.WriteString(" ")
+		default:
+			buf.WriteString(blockText(c))
+		}
+	}
+	return buf.String()
+}
+
+// selectorOf returns a CSS selector matching the element alone, which starts from the closest
+// ancestor with an ID, or from the root.
+func selectorOf(n *html.Node) string {
+	parts := []string{}
+	for ; n != nil && n.Type == html.ElementNode; n = n.Parent {
+		for _, attr := range n.Attr {
+			if attr.Key == "id" && attr.Val != "" && !strings.ContainsAny(attr.Val, " \t\"'.:#[]()>+~") {
+				return strings.Join(append([]string{"#" + attr.Val}, reverse(parts)...), " > ")
+			}
+		}
+		if n.Data == "html" || n.Data == "body" {
+			parts = append(parts, n.Data)
+			continue
+		}
+
+		index := 1
+		for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+			if s.Type == html.ElementNode {
+				index++
+			}
+		}
+		parts = append(parts, fmt.Sprintf("%s:nth-child(%d)", n.Data, index))
+	}
+	return strings.Join(reverse(parts), " > ")
+}
+
+// reverse returns the strings in reverse order.
+func reverse(a []string) []string {
+	r := make([]string, len(a))
+	for i, s := range a {
+		r[len(a)-1-i] = s
+	}
+	return r
+}
+
+// CrawlSite crawls the site until no more page is found in the scope or the maximum number of visits
+// is reached. The robots.txt of the site is respected.
+func CrawlSite(site *Site) error {
+	ext, err := NewSiteExtender(site)
+	if err != nil {
+		return err
+	}
+
+	opts := gocrawl.NewOptions(ext)
+	opts.RobotUserAgent = "CCBot"
+	opts.UserAgent = "TypoSpider"
+	opts.CrawlDelay = site.Delay
+	opts.LogFlags = gocrawl.LogError
+	opts.MaxVisits = site.MaxVisits
+
+	c := gocrawl.NewCrawlerWithOptions(opts)
+	return c.Run(site.Root)
+}
@@ -0,0 +1,119 @@
+package gitea
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/huangjiuyuan/typospider/github"
+	"github.com/huangjiuyuan/typospider/util/rest"
+)
+
+// Number of tree entries requested in a page.
+const pageSize = 1000
+
+// Visitor is the agent for requesting Gitea API. Gitea serves trees in the same model as GitHub, and
+// blobs encoded in base64.
+type Visitor struct {
+	// Whether visiting a tree recursively.
+	Recursive bool
+	// For authorization, which is skipped if it is empty.
+	Token string
+	// BaseURL of Gitea API, like "https://gitea.com/api/v1", without a trailing slash.
+	BaseURL string
+	// HTTP client of the requests, which defaults to http.DefaultClient if it is nil.
+	Client *http.Client
+}
+
+// tree is a page of a Gitea tree.
+type tree struct {
+	github.Tree
+	// Page of the entries.
+	Page int `json:"page"`
+	// Total number of entries in all pages.
+	TotalCount int `json:"total_count"`
+}
+
+// blob is a Gitea blob.
+type blob struct {
+	// Content of the blob.
+	Content string `json:"content"`
+	// Encoding of the content, like "base64".
+	Encoding string `json:"encoding"`
+}
+
+// NewVisitor creates a visitor for requesting the Gitea API at the base URL.
+func NewVisitor(recursive bool, token string, baseURL string) (*Visitor, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("cannot use an empty base URL")
+	}
+
+	github.AddSecret(token)
+	v := &Visitor{
+		Recursive: recursive,
+		Token:     token,
+		BaseURL:   baseURL,
+	}
+	return v, nil
+}
+
+// GetTreeURL returns the API URL of the tree of a ref in a repository.
+func (vis *Visitor) GetTreeURL(owner string, repo string, ref string) string {
+	return fmt.Sprintf("%s/repos/%s/%s/git/trees/%s", strings.TrimSuffix(vis.BaseURL, "/"), owner, repo, ref)
+}
+
+// GetTree gets a tree, whose entries are requested page by page.
+func (vis *Visitor) GetTree(url string) (*github.Tree, bool, error) {
+	var t *github.Tree
+	for page := 1; ; page++ {
+		u := fmt.Sprintf("%s?per_page=%d&page=%d", url, pageSize, page)
+		if vis.Recursive {
+			u += "&recursive=true"
+		}
+		p := new(tree)
+		err := rest.GetJSON(vis.Client, u, vis.header(), p)
+		if err != nil {
+			return nil, vis.Recursive, err
+		}
+
+		if t == nil {
+			t = &p.Tree
+			t.URL = url
+		} else {
+			t.Tree = append(t.Tree, p.Tree.Tree...)
+		}
+		if len(p.Tree.Tree) == 0 || len(t.Tree) >= p.TotalCount {
+			break
+		}
+	}
+
+	return t, vis.Recursive, nil
+}
+
+// GetBlob gets raw content of a blob.
+func (vis *Visitor) GetBlob(url string) ([]byte, error) {
+	b := new(blob)
+	err := rest.GetJSON(vis.Client, url, vis.header(), b)
+	if err != nil {
+		return nil, fmt.Errorf("error on requesting a blob: %s", err)
+	}
+	if b.Encoding != "base64" {
+		return []byte(b.Content), nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(b.Content)
+	if err != nil {
+		return nil, fmt.Errorf("error on decoding a blob: %s", err)
+	}
+	return data, nil
+}
+
+// header returns the header authorizing the requests, which is empty if there is no token.
+func (vis *Visitor) header() http.Header {
+	header := http.Header{}
+	if vis.Token != "" {
+		header.Set("Authorization", "token "+vis.Token)
+	}
+	return header
+}
@@ -0,0 +1,63 @@
+package gitea
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTreeAndBlob(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "token secret-token" {
+			t.Errorf("%s requested with authorization %q", r.URL, auth)
+		}
+		switch r.URL.Path {
+		case "/api/v1/repos/owner/name/git/trees/main":
+			// Entries are served a page at a time.
+			switch r.URL.Query().Get("page") {
+			case "1":
+				fmt.Fprintf(w, `{"sha": "t1", "page": 1, "total_count": 2, "tree": [
+					{"path": "README.md", "mode": "100644", "type": "blob", "size": 9, "sha": "b1", "url": "%s/api/v1/repos/owner/name/git/blobs/b1"}
+				]}`, server.URL)
+			case "2":
+				fmt.Fprint(w, `{"sha": "t1", "page": 2, "total_count": 2, "tree": [
+					{"path": "docs", "mode": "040000", "type": "tree", "sha": "t2"}
+				]}`)
+			default:
+				t.Errorf("unexpected page of %s", r.URL)
+			}
+		case "/api/v1/repos/owner/name/git/blobs/b1":
+			fmt.Fprint(w, `{"content": "IyBSZWFkbWUK", "encoding": "base64"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	vis, err := NewVisitor(true, "secret-token", server.URL+"/api/v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree, recursive, err := vis.GetTree(vis.GetTreeURL("owner", "name", "main"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !recursive || len(tree.Tree) != 2 {
+		t.Fatalf("tree = %+v, recursive = %v, want 2 entries recursively", tree, recursive)
+	}
+	blob := tree.Tree[0]
+	if blob.Path != "README.md" || blob.SHA != "b1" || blob.Size == nil || *blob.Size != 9 {
+		t.Errorf("blob entry = %+v, want README.md of 9 bytes", blob)
+	}
+
+	data, err := vis.GetBlob(blob.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "# Readme\n" {
+		t.Errorf("blob = %q", data)
+	}
+}
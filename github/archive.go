@@ -0,0 +1,44 @@
+package github
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// GetTarballURL returns the API URL of the gzipped tarball of a git reference, like a branch name.
+func (vis *Visitor) GetTarballURL(owner string, repo string, ref string) string {
+	return vis.GetURL("/repos/%s/%s/tarball/%s", owner, repo, ref)
+}
+
+// GetBlobURL returns the API URL of a blob in a repository.
+func (vis *Visitor) GetBlobURL(owner string, repo string, sha string) string {
+	return vis.GetURL("/repos/%s/%s/git/blobs/%s", owner, repo, sha)
+}
+
+// GetTarball requests the gzipped tarball of a repository, and returns the response body to be
+// streamed. The caller must close it.
+func (vis *Visitor) GetTarball(url string) (io.ReadCloser, error) {
+	client := vis.client()
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error on creating new request: %s", err)
+	}
+
+	err = vis.SetAPIAgent(req, false)
+	if err != nil {
+		return nil, err
+	}
+	// The tarball is served by a redirect to another host, which the client follows.
+	resp, err := vis.do(client, req)
+	if err != nil {
+		return nil, fmt.Errorf("error on requesting a tarball: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("error on requesting a tarball %s: %s: %s", url, resp.Status, Redact(string(b)))
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,279 @@
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenSource provides the tokens for authorizing requests to GitHub API.
+type TokenSource interface {
+	// Token returns a token, or an empty token for anonymous requests.
+	Token() (string, error)
+}
+
+// StaticToken is a token which never changes, like a personal access token.
+type StaticToken string
+
+// Token returns the token.
+func (t StaticToken) Token() (string, error) {
+	AddSecret(string(t))
+	return string(t), nil
+}
+
+// TokenFromEnv returns the token in the first environment variable set among the names, or an empty
+// token if none is set.
+func TokenFromEnv(names ...string) StaticToken {
+	for _, name := range names {
+		if token := strings.TrimSpace(os.Getenv(name)); token != "" {
+			return StaticToken(token)
+		}
+	}
+	return ""
+}
+
+// TokenFromFile reads a token from a file, like a mounted secret.
+func TokenFromFile(path string) (StaticToken, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error on reading token file: %s", err)
+	}
+	return StaticToken(strings.TrimSpace(string(b))), nil
+}
+
+// TokenFromGitCredential asks the git credential helpers configured for the user for the password of
+// the host, which is the token of GitHub and GitHub Enterprise. An empty token is returned if no helper
+// knows the host, or git fails to ask them.
+func TokenFromGitCredential(host string) (StaticToken, error) {
+	cmd := exec.Command("git", "credential", "fill")
+	cmd.Stdin = strings.NewReader("protocol=https\nhost=" + host + "\n\n")
+	// Never prompt for a password on the terminal.
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS=")
+	out, err := cmd.Output()
+	if _, ok := err.(*exec.ExitError); ok {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error on running git credential: %s", err)
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, "password=") {
+			return StaticToken(strings.TrimPrefix(line, "password=")), nil
+		}
+	}
+	return "", nil
+}
+
+// AppTokenSource provides the installation tokens of a GitHub App. The tokens are requested with a
+// JWT signed by the private key of the app, and are refreshed before they expire.
+type AppTokenSource struct {
+	// ID of the GitHub App.
+	AppID int64
+	// ID of the installation of the app on an organization or a user.
+	InstallationID int64
+	// Private key of the app.
+	Key *rsa.PrivateKey
+	// BaseURL of GitHub API, without a trailing slash.
+	BaseURL string
+	// HTTP client of the requests, which defaults to http.DefaultClient if it is nil.
+	Client *http.Client
+
+	// Lock of the token.
+	mu sync.Mutex
+	// Current installation token.
+	token string
+	// Time when the current token expires.
+	expires time.Time
+}
+
+// Refresh margin of installation tokens, which are valid for an hour.
+const tokenRefreshMargin = 5 * time.Minute
+
+// NewAppTokenSource returns an AppTokenSource of the installation of an app, whose private key is read
+// from a PEM file downloaded from the settings of the app.
+func NewAppTokenSource(appID int64, installationID int64, keyPath string) (*AppTokenSource, error) {
+	b, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("error on reading private key: %s", err)
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("error on decoding private key: no PEM data found")
+	}
+
+	var key *rsa.PrivateKey
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		var k interface{}
+		k, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err == nil {
+			var ok bool
+			if key, ok = k.(*rsa.PrivateKey); !ok {
+				err = fmt.Errorf("not an RSA key")
+			}
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error on parsing private key: %s", err)
+	}
+
+	return &AppTokenSource{
+		AppID:          appID,
+		InstallationID: installationID,
+		Key:            key,
+		BaseURL:        DefaultBaseURL,
+	}, nil
+}
+
+// Token returns the current installation token, which is refreshed if it expires soon.
+func (src *AppTokenSource) Token() (string, error) {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	if src.token != "" && time.Now().Add(tokenRefreshMargin).Before(src.expires) {
+		return src.token, nil
+	}
+
+	jwt, err := src.JWT(time.Now())
+	if err != nil {
+		return "", err
+	}
+	u := fmt.Sprintf("%s/app/installations/%d/access_tokens", strings.TrimSuffix(src.BaseURL, "/"), src.InstallationID)
+	req, err := http.NewRequest("POST", u, nil)
+	if err != nil {
+		return "", fmt.Errorf("error on creating new request: %s", err)
+	}
+	req.Header.Add("User-Agent", `CCBot`)
+	req.Header.Add("Authorization", "Bearer "+jwt)
+	req.Header.Add("Accept", "application/vnd.github.v3+json")
+
+	client := src.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error on requesting an installation token: %s", Redact(err.Error()))
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error on reading an installation token response: %s", err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("error on requesting an installation token: %s: %s", resp.Status, Redact(string(b)))
+	}
+
+	var out struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	err = json.Unmarshal(b, &out)
+	if err != nil {
+		return "", fmt.Errorf("error on parsing an installation token: %s", err)
+	}
+	AddSecret(out.Token)
+	src.token, src.expires = out.Token, out.ExpiresAt
+	return src.token, nil
+}
+
+// JWT returns a JSON Web Token of the app issued at the time, which is valid for 10 minutes. The
+// issue time is set back a minute against clock drift.
+func (src *AppTokenSource) JWT(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", fmt.Errorf("error on encoding JWT header: %s", err)
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": src.AppID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error on encoding JWT claims: %s", err)
+	}
+
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, src.Key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("error on signing JWT: %s", err)
+	}
+	jwt := unsigned + "." + enc.EncodeToString(sig)
+	AddSecret(jwt)
+	return jwt, nil
+}
+
+// Secrets redacted from logs and errors.
+var secrets = struct {
+	sync.RWMutex
+	values map[string]bool
+}{values: make(map[string]bool)}
+
+// Minimum length of a secret to be redacted, so that short values do not garble messages.
+const minSecretLength = 8
+
+// AddSecret adds a secret, like a token, to be redacted from logs and errors.
+func AddSecret(secret string) {
+	if len(secret) < minSecretLength {
+		return
+	}
+	secrets.Lock()
+	defer secrets.Unlock()
+	secrets.values[secret] = true
+	// Secrets may also appear escaped in URLs.
+	secrets.values[url.QueryEscape(secret)] = true
+}
+
+// Redact replaces the secrets in a message with "[REDACTED]".
+func Redact(s string) string {
+	secrets.RLock()
+	defer secrets.RUnlock()
+	for secret := range secrets.values {
+		if strings.Contains(s, secret) {
+			s = strings.Replace(s, secret, "[REDACTED]", -1)
+		}
+	}
+	return s
+}
+
+// redactError returns the error with the secrets redacted from its message.
+func redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := Redact(err.Error())
+	if msg == err.Error() {
+		return err
+	}
+	return fmt.Errorf("%s", msg)
+}
+
+// CredentialHost returns the host of git credentials of the GitHub API at the base URL, like
+// "github.com" for "https://api.github.com".
+func CredentialHost(baseURL string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(u.Host, "api.")
+}
@@ -1,7 +1,10 @@
 package github
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 )
@@ -14,30 +17,61 @@ type Blob struct {
 	Size int `json:"size"`
 	// SHA is the identifier.
 	SHA string `json:"sha"`
+	// File mode of the tree entry, like ModeExecutable, or empty if it is unknown.
+	Mode string `json:"mode"`
 	// URL is for requesting GitHub API.
 	URL string `json:"url"`
+	// Full name of the repository containing the blob, like "owner/name".
+	Repo string `json:"repo"`
 	// Data contains the raw content of a blob.
 	Data *[]byte `json:"data"`
 }
 
 // GetBlob gets raw content of a GitHub blob.
 func (vis *Visitor) GetBlob(url string) ([]byte, error) {
-	client := &http.Client{}
+	body, err := vis.OpenBlob(url)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("error on reading a blob response: %s", err)
+	}
+	return data, nil
+}
+
+// OpenBlob requests raw content of a GitHub blob, and returns the response body to be streamed. The
+// caller must close it.
+func (vis *Visitor) OpenBlob(url string) (io.ReadCloser, error) {
+	client := vis.client()
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error on creating new request: %s", err)
 	}
 
-	vis.SetAPIAgent(req, true)
-	resp, err := client.Do(req)
+	err = vis.SetAPIAgent(req, true)
 	if err != nil {
-		return nil, fmt.Errorf("error on requesting a blob: %s", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
+	resp, err := vis.do(client, req)
 	if err != nil {
-		return nil, fmt.Errorf("error on reading a blob response: %s", err)
+		return nil, fmt.Errorf("error on requesting a blob: %s", err)
 	}
-	return body, nil
+	// Error responses, like rate limits, must not be checked as the content of the blob.
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("error on requesting a blob %s: %s: %s", url, resp.Status, Redact(string(b)))
+	}
+	return resp.Body, nil
+}
+
+// BlobSHA returns the git object identifier of a blob with the data.
+func BlobSHA(data []byte) string {
+	hash := sha1.New()
+	fmt.Fprintf(hash, "blob %d\x00", len(data))
+	hash.Write(data)
+	return hex.EncodeToString(hash.Sum(nil))
 }
@@ -0,0 +1,102 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Rate limit resources of GitHub API, which are reported by the X-RateLimit-Resource header. Each
+// resource has its own rate limit.
+const (
+	// ResourceCore is the rate limit of the REST API.
+	ResourceCore = "core"
+	// ResourceGraphQL is the rate limit of the GraphQL API.
+	ResourceGraphQL = "graphql"
+)
+
+// Budget is a rate limit budget of GitHub API, which can be shared by several visitors, like those
+// scanning the repositories of an organization. Requests are spaced by the interval, and are held
+// back when the rate limit of their resource reported by GitHub is used up until it is reset.
+type Budget struct {
+	// Minimum interval between requests.
+	Interval time.Duration
+
+	// Lock of the fields below.
+	mu sync.Mutex
+	// Time when the next request can be sent.
+	next time.Time
+	// Rate limits reported by GitHub, keyed by their resources.
+	limits map[string]*rateLimit
+}
+
+// rateLimit is the rate limit of a resource reported by GitHub.
+type rateLimit struct {
+	// Remaining requests.
+	remaining int
+	// Time when the rate limit is reset.
+	reset time.Time
+}
+
+// NewBudget returns a Budget spacing requests by the interval.
+func NewBudget(interval time.Duration) *Budget {
+	return &Budget{
+		Interval: interval,
+		limits:   make(map[string]*rateLimit),
+	}
+}
+
+// Wait blocks until a request to the resource, like ResourceCore, can be sent within the budget.
+func (b *Budget) Wait(resource string) {
+	b.mu.Lock()
+	now := time.Now()
+	at := b.next
+	if limit, ok := b.limits[resource]; ok && limit.remaining == 0 && limit.reset.After(at) {
+		fmt.Printf("[Warning] API rate limit of %s used up, waiting until %s\n", resource, limit.reset.Format(time.RFC3339))
+		at = limit.reset
+		delete(b.limits, resource)
+	}
+	if at.Before(now) {
+		at = now
+	}
+	b.next = at.Add(b.Interval)
+	b.mu.Unlock()
+
+	time.Sleep(at.Sub(now))
+}
+
+// Update updates the budget with the rate limit headers of a response. The headers apply to the
+// resource named by the X-RateLimit-Resource header, which defaults to ResourceCore.
+func (b *Budget) Update(resp *http.Response) {
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+	resource := resp.Header.Get("X-RateLimit-Resource")
+	if resource == "" {
+		resource = ResourceCore
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.limits[resource] = &rateLimit{
+		remaining: remaining,
+		reset:     time.Unix(reset, 0),
+	}
+}
+
+// Remaining returns the remaining requests to the resource reported by GitHub, or -1 if unknown.
+func (b *Budget) Remaining(resource string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if limit, ok := b.limits[resource]; ok {
+		return limit.remaining
+	}
+	return -1
+}
@@ -0,0 +1,87 @@
+package github
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultUploadURL is the upload URL of GitHub API.
+const DefaultUploadURL = "https://uploads.github.com"
+
+// ClientOptions are the options of the HTTP client of GitHub API, which are needed by GitHub
+// Enterprise Server instances behind proxies or signed by internal certificate authorities.
+type ClientOptions struct {
+	// Path of a PEM bundle of certificate authorities trusted besides the system ones.
+	CAFile string
+	// URL of the proxy, like "http://proxy.example.com:3128", or empty for the proxy of the
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+	Proxy string
+}
+
+// NewClient returns an HTTP client with the options. The transport is a clone of
+// http.DefaultTransport, so that its timeouts and connection pooling are kept.
+func NewClient(opts ClientOptions) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.Proxy != "" {
+		u, err := url.Parse(opts.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("error on parsing proxy URL: %s", err)
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+	if opts.CAFile != "" {
+		b, err := ioutil.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("error on reading CA bundle: %s", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("error on parsing CA bundle: no certificates found in %s", opts.CAFile)
+		}
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.RootCAs = pool
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// EnterpriseURLs returns the API base URL and the upload URL of a GitHub Enterprise Server instance,
+// like "https://github.example.com/api/v3" and "https://github.example.com/api/uploads". The base URL
+// may be given as the URL of the instance, to which the API path is appended. The upload URL is
+// derived from the base URL if it is empty.
+func EnterpriseURLs(baseURL string, uploadURL string) (string, string, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return "", "", fmt.Errorf("error on parsing base URL: %s", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return "", "", fmt.Errorf("invalid base URL %q", baseURL)
+	}
+	if u.Host == "api.github.com" {
+		return DefaultBaseURL, DefaultUploadURL, nil
+	}
+	if !strings.HasSuffix(u.Path, "/api/v3") {
+		u.Path += "/api/v3"
+	}
+
+	if uploadURL == "" {
+		up := *u
+		up.Path = strings.TrimSuffix(u.Path, "/v3") + "/uploads"
+		return u.String(), up.String(), nil
+	}
+	up, err := url.Parse(strings.TrimSuffix(uploadURL, "/"))
+	if err != nil {
+		return "", "", fmt.Errorf("error on parsing upload URL: %s", err)
+	}
+	return u.String(), up.String(), nil
+}
@@ -0,0 +1,122 @@
+package github
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Path of blob URLs of the REST API, like "/repos/owner/name/git/blobs/sha".
+var blobURLExp = regexp.MustCompile(`/repos/([^/]+)/([^/]+)/git/blobs/([0-9a-f]{40}|[0-9a-f]{64})$`)
+
+// graphQLBlob is a blob in a GraphQL response.
+type graphQLBlob struct {
+	// Text of the blob, which is null if the blob is binary.
+	Text *string `json:"text"`
+	// Whether the blob is binary.
+	IsBinary bool `json:"isBinary"`
+	// Whether the text is truncated, which happens to large blobs.
+	IsTruncated bool `json:"isTruncated"`
+	// Size of the blob in bytes, which differs from the size of the text if the blob is not UTF-8.
+	ByteSize int `json:"byteSize"`
+}
+
+// GetGraphQLURL returns the URL of the GraphQL API, like "https://api.github.com/graphql", or
+// "https://github.example.com/api/graphql" for GitHub Enterprise Server.
+func (vis *Visitor) GetGraphQLURL() string {
+	base := strings.TrimSuffix(vis.BaseURL, "/")
+	if strings.HasSuffix(base, "/api/v3") {
+		return strings.TrimSuffix(base, "/v3") + "/graphql"
+	}
+	return base + "/graphql"
+}
+
+// GetBlobs gets raw content of blobs by their REST API URLs in bulk, with a GraphQL query per
+// repository. The content is keyed by the URLs. Binary and truncated blobs, blobs whose text is not
+// exactly their bytes, like Latin-1 or UTF-16 blobs transcoded by GitHub, and blobs whose URLs are not
+// blob URLs of the REST API, are left out, which can be fetched one by one with GetBlob, so that their
+// encodings are detected from the raw bytes.
+func (vis *Visitor) GetBlobs(urls []string) (map[string][]byte, error) {
+	if vis.Auth == nil {
+		return nil, fmt.Errorf("error on requesting blobs: GraphQL API requires authentication")
+	}
+
+	// Group the blobs by their repositories.
+	repos := map[[2]string]map[string][]string{}
+	for _, u := range urls {
+		m := blobURLExp.FindStringSubmatch(u)
+		if m == nil {
+			continue
+		}
+		key := [2]string{m[1], m[2]}
+		if repos[key] == nil {
+			repos[key] = map[string][]string{}
+		}
+		repos[key][m[3]] = append(repos[key][m[3]], u)
+	}
+
+	blobs := map[string][]byte{}
+	for key, shas := range repos {
+		texts, err := vis.getBlobTexts(key[0], key[1], shas)
+		if err != nil {
+			return blobs, err
+		}
+		for sha, text := range texts {
+			for _, u := range shas[sha] {
+				blobs[u] = text
+			}
+		}
+	}
+	return blobs, nil
+}
+
+// getBlobTexts gets the text of the blobs of a repository by their SHAs in one GraphQL query.
+func (vis *Visitor) getBlobTexts(owner string, repo string, shas map[string][]string) (map[string][]byte, error) {
+	aliases := map[string]string{}
+	var query bytes.Buffer
+	query.WriteString("query($owner: String!, $name: String!) { repository(owner: $owner, name: $name) {")
+	for sha := range shas {
+		alias := fmt.Sprintf("b%d", len(aliases))
+		aliases[alias] = sha
+		// SHAs are checked to be hexadecimal, so they are safe to inline.
+		fmt.Fprintf(&query, " %s: object(oid: %q) { ... on Blob { text isBinary isTruncated byteSize } }", alias, sha)
+	}
+	query.WriteString(" } }")
+
+	in := map[string]interface{}{
+		"query": query.String(),
+		"variables": map[string]string{
+			"owner": owner,
+			"name":  repo,
+		},
+	}
+	out := struct {
+		Data struct {
+			Repository map[string]*graphQLBlob `json:"repository"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}{}
+	err := vis.request("POST", vis.GetGraphQLURL(), in, &out)
+	if err != nil {
+		return nil, err
+	}
+	if len(out.Errors) > 0 && out.Data.Repository == nil {
+		return nil, fmt.Errorf("error on querying blobs of %s/%s: %s", owner, repo, out.Errors[0].Message)
+	}
+
+	texts := map[string][]byte{}
+	for alias, blob := range out.Data.Repository {
+		if blob == nil || blob.Text == nil || blob.IsBinary || blob.IsTruncated {
+			continue
+		}
+		// The text is decoded by GitHub, which is lossy for blobs in other encodings than UTF-8.
+		if len(*blob.Text) != blob.ByteSize {
+			continue
+		}
+		texts[aliases[alias]] = []byte(*blob.Text)
+	}
+	return texts, nil
+}
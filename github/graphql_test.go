@@ -0,0 +1,62 @@
+package github
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGetBlobs(t *testing.T) {
+	utf8SHA := strings.Repeat("1", 40)
+	latin1SHA := strings.Repeat("2", 40)
+	binarySHA := strings.Repeat("3", 40)
+	text := func(s string) *string { return &s }
+	blobs := map[string]*graphQLBlob{
+		utf8SHA: {Text: text("// Über.\n"), ByteSize: 10},
+		// "// Über.\n" in Latin-1 is 9 bytes, which GitHub transcodes to UTF-8.
+		latin1SHA: {Text: text("// Über.\n"), ByteSize: 9},
+		binarySHA: {IsBinary: true, ByteSize: 4},
+	}
+
+	aliasExp := regexp.MustCompile(`(b\d+): object\(oid: "([0-9a-f]+)"\)`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/graphql" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		var in struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&in)
+		repository := map[string]*graphQLBlob{}
+		for _, m := range aliasExp.FindAllStringSubmatch(in.Query, -1) {
+			repository[m[1]] = blobs[m[2]]
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"repository": repository},
+		})
+	}))
+	defer server.Close()
+
+	vis, err := NewVisitor(true, "secret-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vis.BaseURL = server.URL
+
+	urls := []string{}
+	for _, sha := range []string{utf8SHA, latin1SHA, binarySHA} {
+		urls = append(urls, vis.GetBlobURL("owner", "name", sha))
+	}
+	data, err := vis.GetBlobs(urls)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the UTF-8 blob is got in bulk, and the others are left to GetBlob.
+	if len(data) != 1 || string(data[urls[0]]) != "// Über.\n" {
+		t.Errorf("blobs = %q, want the UTF-8 blob only", data)
+	}
+}
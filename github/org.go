@@ -0,0 +1,16 @@
+package github
+
+// ListRepositories lists a page of the repositories of an organization. Pages start from 1.
+func (vis *Visitor) ListRepositories(org string, page int) ([]*Repository, error) {
+	repos := []*Repository{}
+	err := vis.request("GET", vis.GetURL("/orgs/%s/repos?type=all&per_page=%d&page=%d", org, PageSize, page), nil, &repos)
+	if err != nil {
+		return nil, err
+	}
+	return repos, nil
+}
+
+// GetTreeURL returns the API URL of the tree of a git reference, like a branch name.
+func (vis *Visitor) GetTreeURL(owner string, repo string, ref string) string {
+	return vis.GetURL("/repos/%s/%s/git/trees/%s", owner, repo, ref)
+}
@@ -0,0 +1,281 @@
+package github
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// PageSize is the number of items requested in a page of a list.
+const PageSize = 100
+
+// Repository contains metadata of a GitHub repository.
+type Repository struct {
+	// Name of the repository.
+	Name string `json:"name"`
+	// Full name of the repository, in the form of "owner/name".
+	FullName string `json:"full_name"`
+	// Owner of the repository.
+	Owner User `json:"owner"`
+	// Default branch of the repository.
+	DefaultBranch string `json:"default_branch"`
+	// Whether the repository is a fork.
+	Fork bool `json:"fork"`
+	// Whether the repository is archived.
+	Archived bool `json:"archived"`
+	// Primary language of the repository, like "Go".
+	Language string `json:"language"`
+	// Topics of the repository.
+	Topics []string `json:"topics"`
+}
+
+// User contains metadata of a GitHub user.
+type User struct {
+	// Login name of the user.
+	Login string `json:"login"`
+}
+
+// Reference is a git reference.
+type Reference struct {
+	// Full name of the reference, like "refs/heads/master".
+	Ref string `json:"ref"`
+	// Object the reference points to.
+	Object Object `json:"object"`
+}
+
+// Object is a git object.
+type Object struct {
+	// Type of the object.
+	Type string `json:"type"`
+	// SHA is the identifier.
+	SHA string `json:"sha"`
+}
+
+// Commit is a git commit.
+type Commit struct {
+	// SHA is the identifier.
+	SHA string `json:"sha"`
+	// Commit message.
+	Message string `json:"message"`
+	// Tree of the commit.
+	Tree Object `json:"tree"`
+}
+
+// TreeEntry is an entry to create in a git tree.
+type TreeEntry struct {
+	// Path of the entry relative to the tree.
+	Path string `json:"path"`
+	// File mode of the entry, like "100644".
+	Mode string `json:"mode"`
+	// Type of the entry, like "blob".
+	Type string `json:"type"`
+	// Content of the entry, which is stored as a new blob.
+	Content string `json:"content"`
+}
+
+// PullRequest contains metadata of a GitHub pull request.
+type PullRequest struct {
+	// Number of the pull request.
+	Number int `json:"number"`
+	// Title of the pull request.
+	Title string `json:"title"`
+	// Body of the pull request.
+	Body string `json:"body"`
+	// URL of the pull request page.
+	HTMLURL string `json:"html_url"`
+	// Author of the pull request.
+	User User `json:"user"`
+	// Name of the branch where changes are implemented, like "user:branch".
+	Head string `json:"-"`
+	// Name of the branch where changes are pulled into.
+	Base string `json:"-"`
+}
+
+// GetRepository gets a GitHub repository.
+func (vis *Visitor) GetRepository(owner string, repo string) (*Repository, error) {
+	r := new(Repository)
+	err := vis.request("GET", vis.GetURL("/repos/%s/%s", owner, repo), nil, r)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Fork forks a repository for the authenticated user, and waits until the fork is ready.
+func (vis *Visitor) Fork(owner string, repo string) (*Repository, error) {
+	r := new(Repository)
+	err := vis.request("POST", vis.GetURL("/repos/%s/%s/forks", owner, repo), nil, r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Forking happens asynchronously, so wait until the default branch can be read.
+	for i := 0; i < 10; i++ {
+		_, err = vis.GetReference(r.Owner.Login, r.Name, "heads/"+r.DefaultBranch)
+		if err == nil {
+			return r, nil
+		}
+		time.Sleep(time.Duration(i+1) * time.Second)
+	}
+	return nil, fmt.Errorf("fork %s is not ready: %s", r.FullName, err)
+}
+
+// GetReference gets a git reference, like "heads/master".
+func (vis *Visitor) GetReference(owner string, repo string, ref string) (*Reference, error) {
+	r := new(Reference)
+	err := vis.request("GET", vis.GetURL("/repos/%s/%s/git/ref/%s", owner, repo, ref), nil, r)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// CreateReference creates a git reference, like "refs/heads/fix", pointing to a commit. If the
+// reference exists, like a branch left by a previous run, it is moved to the commit instead.
+func (vis *Visitor) CreateReference(owner string, repo string, ref string, sha string) (*Reference, error) {
+	_, err := vis.GetReference(owner, repo, strings.TrimPrefix(ref, "refs/"))
+	if err == nil {
+		return vis.UpdateReference(owner, repo, ref, sha)
+	}
+
+	in := map[string]string{
+		"ref": ref,
+		"sha": sha,
+	}
+	r := new(Reference)
+	err = vis.request("POST", vis.GetURL("/repos/%s/%s/git/refs", owner, repo), in, r)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// UpdateReference moves a git reference, like "refs/heads/fix", to a commit, even if the commit does
+// not contain the commit the reference points to.
+func (vis *Visitor) UpdateReference(owner string, repo string, ref string, sha string) (*Reference, error) {
+	in := map[string]interface{}{
+		"sha":   sha,
+		"force": true,
+	}
+	r := new(Reference)
+	err := vis.request("PATCH", vis.GetURL("/repos/%s/%s/git/refs/%s", owner, repo, strings.TrimPrefix(ref, "refs/")), in, r)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// RepositoryCommit is a commit listed in a GitHub repository.
+type RepositoryCommit struct {
+	// SHA is the identifier.
+	SHA string `json:"sha"`
+	// Git commit, containing the message.
+	Commit Commit `json:"commit"`
+	// URL of the commit page.
+	HTMLURL string `json:"html_url"`
+}
+
+// ListCommits lists a page of the commits on the default branch of a repository, the most recent
+// first. Pages start from 1.
+func (vis *Visitor) ListCommits(owner string, repo string, page int) ([]*RepositoryCommit, error) {
+	commits := []*RepositoryCommit{}
+	err := vis.request("GET", vis.GetURL("/repos/%s/%s/commits?per_page=%d&page=%d", owner, repo, PageSize, page), nil, &commits)
+	if err != nil {
+		return nil, err
+	}
+	return commits, nil
+}
+
+// GetCommit gets a git commit.
+func (vis *Visitor) GetCommit(owner string, repo string, sha string) (*Commit, error) {
+	c := new(Commit)
+	err := vis.request("GET", vis.GetURL("/repos/%s/%s/git/commits/%s", owner, repo, sha), nil, c)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// CreateTree creates a git tree by applying the entries on top of a base tree.
+func (vis *Visitor) CreateTree(owner string, repo string, base string, entries []*TreeEntry) (*Tree, error) {
+	in := map[string]interface{}{
+		"base_tree": base,
+		"tree":      entries,
+	}
+	t := new(Tree)
+	err := vis.request("POST", vis.GetURL("/repos/%s/%s/git/trees", owner, repo), in, t)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// CreateCommit creates a git commit of a tree.
+func (vis *Visitor) CreateCommit(owner string, repo string, message string, tree string, parents []string) (*Commit, error) {
+	in := map[string]interface{}{
+		"message": message,
+		"tree":    tree,
+		"parents": parents,
+	}
+	c := new(Commit)
+	err := vis.request("POST", vis.GetURL("/repos/%s/%s/git/commits", owner, repo), in, c)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// CreatePullRequest opens a pull request from the head branch to the base branch.
+func (vis *Visitor) CreatePullRequest(owner string, repo string, pr *PullRequest) (*PullRequest, error) {
+	in := map[string]string{
+		"title": pr.Title,
+		"body":  pr.Body,
+		"head":  pr.Head,
+		"base":  pr.Base,
+	}
+	r := new(PullRequest)
+	err := vis.request("POST", vis.GetURL("/repos/%s/%s/pulls", owner, repo), in, r)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Content contains metadata of a file in a GitHub repository.
+type Content struct {
+	// Type of the content, like "file".
+	Type string `json:"type"`
+	// Path of the content.
+	Path string `json:"path"`
+	// Size of the content.
+	Size int `json:"size"`
+	// SHA is the identifier of the blob.
+	SHA string `json:"sha"`
+}
+
+// GetContent gets metadata of a file at a git reference.
+func (vis *Visitor) GetContent(owner string, repo string, path string, ref string) (*Content, error) {
+	// Escape each segment of the path, which may contain characters like "#" or "?".
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	c := new(Content)
+	err := vis.request("GET", vis.GetURL("/repos/%s/%s/contents/%s?ref=%s", owner, repo, strings.Join(segments, "/"), url.QueryEscape(ref)), nil, c)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ListPullRequests lists a page of the pull requests of a repository in all states, the most recent
+// first. Pages start from 1.
+func (vis *Visitor) ListPullRequests(owner string, repo string, page int) ([]*PullRequest, error) {
+	prs := []*PullRequest{}
+	err := vis.request("GET", vis.GetURL("/repos/%s/%s/pulls?state=all&per_page=%d&page=%d", owner, repo, PageSize, page), nil, &prs)
+	if err != nil {
+		return nil, err
+	}
+	return prs, nil
+}
@@ -21,6 +21,20 @@ type Tree struct {
 	Truncated bool `json:"truncated"`
 }
 
+// Modes of tree entries.
+const (
+	// ModeFile is the mode of regular files.
+	ModeFile = "100644"
+	// ModeExecutable is the mode of executable files.
+	ModeExecutable = "100755"
+	// ModeSymlink is the mode of symbolic links, whose blobs contain the link targets.
+	ModeSymlink = "120000"
+	// ModeSubmodule is the mode of git submodules, whose SHAs are the pinned commits.
+	ModeSubmodule = "160000"
+	// ModeTree is the mode of subtrees.
+	ModeTree = "040000"
+)
+
 // Submodule contains metadata of a GitHub submodule.
 type Submodule struct {
 	Path string `json:"path"`
@@ -31,7 +45,8 @@ type Submodule struct {
 	URL  string `json:"url"`
 }
 
-// GetTree gets a GitHub tree.
+// GetTree gets a GitHub tree. If a recursive tree is truncated, the tree is got unrecursively, and
+// its subtrees are still requested recursively.
 func (vis *Visitor) GetTree(url string) (*Tree, bool, error) {
 	if !vis.Recursive {
 		t, err := vis.getTreeUnrecursive(url)
@@ -46,7 +61,6 @@ func (vis *Visitor) GetTree(url string) (*Tree, bool, error) {
 		return nil, true, err
 	}
 	if t.Truncated {
-		vis.Recursive = false
 		t, err := vis.getTreeUnrecursive(url)
 		if err != nil {
 			return nil, false, err
@@ -59,14 +73,17 @@ func (vis *Visitor) GetTree(url string) (*Tree, bool, error) {
 
 // getTreeUnrecursive gets contents with depth of 1 under a tree.
 func (vis *Visitor) getTreeUnrecursive(url string) (*Tree, error) {
-	client := &http.Client{}
+	client := vis.client()
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error on creating new request: %s", err)
 	}
 
-	vis.SetAPIAgent(req, false)
-	resp, err := client.Do(req)
+	err = vis.SetAPIAgent(req, false)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := vis.do(client, req)
 	if err != nil {
 		return nil, fmt.Errorf("error on requesting a tree: %s", err)
 	}
@@ -92,14 +109,17 @@ func (vis *Visitor) getTreeUnrecursive(url string) (*Tree, error) {
 
 // getTreeRecursive gets all contents under a tree recursively.
 func (vis *Visitor) getTreeRecursive(url string) (*Tree, error) {
-	client := &http.Client{}
+	client := vis.client()
 	req, err := http.NewRequest("GET", url+"?recursive=1", nil)
 	if err != nil {
 		return nil, fmt.Errorf("error on creating new request: %s", err)
 	}
 
-	vis.SetAPIAgent(req, false)
-	resp, err := client.Do(req)
+	err = vis.SetAPIAgent(req, false)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := vis.do(client, req)
 	if err != nil {
 		return nil, fmt.Errorf("error on requesting a recursive tree: %s", err)
 	}
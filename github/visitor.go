@@ -1,31 +1,149 @@
 package github
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"net/http"
+	"strings"
 )
 
+// DefaultBaseURL is the base URL of GitHub API.
+const DefaultBaseURL = "https://api.github.com"
+
 // Visitor is the agent for requesting GitHub API.
 type Visitor struct {
 	// Whether visiting a tree recursively.
 	Recursive bool
-	// For authorization.
-	Token string
+	// Source of tokens for authorization, or nil for anonymous requests.
+	Auth TokenSource
+	// BaseURL of GitHub API, without a trailing slash.
+	BaseURL string
+	// UploadURL of GitHub API, without a trailing slash.
+	UploadURL string
+	// HTTP client of the requests, which defaults to http.DefaultClient if it is nil.
+	Client *http.Client
+	// Budget of requests, which is unlimited if it is nil.
+	Budget *Budget
 }
 
-// NewVisitor creates a visitor for requesting GitHub API.
+// NewVisitor creates a visitor for requesting GitHub API with a static token, or anonymously if the
+// token is empty.
 func NewVisitor(recursive bool, token string) (*Visitor, error) {
 	v := &Visitor{
 		Recursive: recursive,
-		Token:     token,
+		BaseURL:   DefaultBaseURL,
+		UploadURL: DefaultUploadURL,
+	}
+	if token != "" {
+		AddSecret(token)
+		v.Auth = StaticToken(token)
 	}
 	return v, nil
 }
 
-// SetAPIAgent sets the request header, including User-Agent, Authorization and Accept fields.
-func (vis *Visitor) SetAPIAgent(req *http.Request, raw bool) {
+// SetAPIAgent sets the request header, including User-Agent, Authorization and Accept fields. The
+// Authorization field is skipped for anonymous requests.
+func (vis *Visitor) SetAPIAgent(req *http.Request, raw bool) error {
 	req.Header.Add("User-Agent", `CCBot`)
-	req.Header.Add("Authorization", "token "+vis.Token)
+	if vis.Auth != nil {
+		token, err := vis.Auth.Token()
+		if err != nil {
+			return fmt.Errorf("error on getting a token: %s", err)
+		}
+		if token != "" {
+			req.Header.Add("Authorization", "token "+token)
+		}
+	}
 	if raw {
 		req.Header.Add("Accept", `application/vnd.github.v3.raw`)
 	}
+	return nil
+}
+
+// client returns the HTTP client of the visitor.
+func (vis *Visitor) client() *http.Client {
+	if vis.Client == nil {
+		return http.DefaultClient
+	}
+	return vis.Client
+}
+
+// do sends a request within the budget of the visitor.
+func (vis *Visitor) do(client *http.Client, req *http.Request) (*http.Response, error) {
+	if vis.Budget == nil {
+		resp, err := client.Do(req)
+		return resp, redactError(err)
+	}
+
+	vis.Budget.Wait(vis.resource(req))
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, redactError(err)
+	}
+	vis.Budget.Update(resp)
+	return resp, nil
+}
+
+// resource returns the rate limit resource of a request, which is ResourceGraphQL for queries of the
+// GraphQL API, and ResourceCore otherwise.
+func (vis *Visitor) resource(req *http.Request) string {
+	if req.URL.String() == vis.GetGraphQLURL() {
+		return ResourceGraphQL
+	}
+	return ResourceCore
+}
+
+// GetURL returns the url of an API path.
+func (vis *Visitor) GetURL(format string, a ...interface{}) string {
+	return strings.TrimSuffix(vis.BaseURL, "/") + fmt.Sprintf(format, a...)
+}
+
+// request sends a request with a JSON body to GitHub API, and parses the JSON response into out.
+// The body and the response are skipped if they are nil.
+func (vis *Visitor) request(method string, url string, in interface{}, out interface{}) error {
+	var body []byte
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("error on encoding a request body: %s", err)
+		}
+		body = b
+	}
+
+	client := vis.client()
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error on creating new request: %s", err)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	err = vis.SetAPIAgent(req, false)
+	if err != nil {
+		return err
+	}
+	resp, err := vis.do(client, req)
+	if err != nil {
+		return fmt.Errorf("error on requesting %s %s: %s", method, url, err)
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error on reading a response: %s", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("error on requesting %s %s: %s: %s", method, url, resp.Status, Redact(string(b)))
+	}
+
+	if out != nil {
+		err = json.Unmarshal(b, out)
+		if err != nil {
+			return fmt.Errorf("error on parsing a response: %s", err)
+		}
+	}
+	return nil
 }
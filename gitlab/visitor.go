@@ -0,0 +1,138 @@
+package gitlab
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/huangjiuyuan/typospider/github"
+	"github.com/huangjiuyuan/typospider/util/rest"
+)
+
+// DefaultBaseURL is the base URL of GitLab API.
+const DefaultBaseURL = "https://gitlab.com/api/v4"
+
+// Number of tree entries requested in a page.
+const pageSize = 100
+
+// Visitor is the agent for requesting GitLab API. It provides the trees and blobs of a project in
+// the same model as GitHub.
+type Visitor struct {
+	// Whether visiting a tree recursively.
+	Recursive bool
+	// For authorization, which is skipped if it is empty.
+	Token string
+	// BaseURL of GitLab API, without a trailing slash.
+	BaseURL string
+	// HTTP client of the requests, which defaults to http.DefaultClient if it is nil.
+	Client *http.Client
+}
+
+// entry is an entry of a GitLab tree.
+type entry struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Path string `json:"path"`
+	Mode string `json:"mode"`
+}
+
+// NewVisitor creates a visitor for requesting GitLab API.
+func NewVisitor(recursive bool, token string) (*Visitor, error) {
+	github.AddSecret(token)
+	v := &Visitor{
+		Recursive: recursive,
+		Token:     token,
+		BaseURL:   DefaultBaseURL,
+	}
+	return v, nil
+}
+
+// GetTreeURL returns the API URL of the tree of a ref in a project, like "group/subgroup" and "name".
+func (vis *Visitor) GetTreeURL(owner string, repo string, ref string) string {
+	return fmt.Sprintf("%s/projects/%s/repository/tree?ref=%s", strings.TrimSuffix(vis.BaseURL, "/"), url.PathEscape(owner+"/"+repo), url.QueryEscape(ref))
+}
+
+// GetTree gets a tree. The URL of a subtree is the URL of the tree with the path of the subtree.
+func (vis *Visitor) GetTree(u string) (*github.Tree, bool, error) {
+	base, err := url.Parse(u)
+	if err != nil {
+		return nil, false, fmt.Errorf("error on parsing tree URL: %s", err)
+	}
+	endpoint := base.Scheme + "://" + base.Host + base.EscapedPath()
+	repository := strings.TrimSuffix(endpoint, "/tree")
+
+	t := &github.Tree{
+		Path: base.Query().Get("path"),
+		URL:  u,
+	}
+	for page := 1; ; page++ {
+		q := base.Query()
+		q.Set("per_page", fmt.Sprint(pageSize))
+		q.Set("page", fmt.Sprint(page))
+		if vis.Recursive {
+			q.Set("recursive", "true")
+		}
+		entries := []*entry{}
+		err := rest.GetJSON(vis.Client, endpoint+"?"+q.Encode(), vis.header(), &entries)
+		if err != nil {
+			return nil, vis.Recursive, err
+		}
+
+		for _, e := range entries {
+			// Sizes are not listed in GitLab trees, so they are left unknown, and large blobs are
+			// told by streaming them.
+			sm := &github.Submodule{
+				Path: e.Path,
+				Mode: e.Mode,
+				Type: e.Type,
+				SHA:  e.ID,
+				URL:  repository + "/blobs/" + e.ID + "/raw",
+			}
+			if !vis.Recursive {
+				// Paths are relative to the tree in non-recursive mode.
+				sm.Path = e.Name
+			}
+			if e.Type == "tree" {
+				q := base.Query()
+				q.Set("path", e.Path)
+				sm.URL = endpoint + "?" + q.Encode()
+			}
+			t.Tree = append(t.Tree, sm)
+		}
+		if len(entries) < pageSize {
+			break
+		}
+	}
+
+	return t, vis.Recursive, nil
+}
+
+// GetBlob gets raw content of a blob.
+func (vis *Visitor) GetBlob(u string) ([]byte, error) {
+	body, err := rest.Get(vis.Client, u, vis.header())
+	if err != nil {
+		return nil, fmt.Errorf("error on requesting a blob: %s", err)
+	}
+	return body, nil
+}
+
+// OpenBlob requests raw content of a blob, and returns the body to be streamed.
+func (vis *Visitor) OpenBlob(u string) (io.ReadCloser, error) {
+	body, err := rest.Open(vis.Client, u, vis.header())
+	if err != nil {
+		return nil, fmt.Errorf("error on requesting a blob: %s", err)
+	}
+	return body, nil
+}
+
+// header returns the header authorizing the requests, which is empty if there is no token.
+func (vis *Visitor) header() http.Header {
+	header := http.Header{}
+	if vis.Token != "" {
+		header.Set("PRIVATE-TOKEN", vis.Token)
+	}
+	return header
+}
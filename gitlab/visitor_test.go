@@ -0,0 +1,74 @@
+package gitlab
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTreeAndBlob(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := r.Header.Get("PRIVATE-TOKEN"); token != "secret-token" {
+			t.Errorf("%s requested with token %q", r.URL, token)
+		}
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/group%2Fname/repository/tree":
+			if ref := r.URL.Query().Get("ref"); ref != "main" {
+				t.Errorf("tree requested at %q, want main", ref)
+			}
+			fmt.Fprint(w, `[
+				{"id": "d1", "name": "docs", "type": "tree", "path": "docs", "mode": "040000"},
+				{"id": "b1", "name": "README.md", "type": "blob", "path": "docs/README.md", "mode": "100644"}
+			]`)
+		case "/api/v4/projects/group%2Fname/repository/blobs/b1/raw":
+			fmt.Fprint(w, "# Readme\n")
+		default:
+			http.Error(w, `{"message": "404 Blob Not Found"}`, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	vis, err := NewVisitor(true, "secret-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vis.BaseURL = server.URL + "/api/v4"
+
+	tree, recursive, err := vis.GetTree(vis.GetTreeURL("group", "name", "main"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !recursive || len(tree.Tree) != 2 {
+		t.Fatalf("tree = %+v, recursive = %v, want 2 entries recursively", tree, recursive)
+	}
+	blob := tree.Tree[1]
+	if blob.Path != "docs/README.md" || blob.SHA != "b1" || blob.Mode != "100644" || blob.Size != nil {
+		t.Errorf("blob entry = %+v, want docs/README.md of an unknown size", blob)
+	}
+
+	data, err := vis.GetBlob(blob.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "# Readme\n" {
+		t.Errorf("blob = %q", data)
+	}
+	body, err := vis.OpenBlob(blob.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = ioutil.ReadAll(body)
+	body.Close()
+	if err != nil || string(data) != "# Readme\n" {
+		t.Errorf("streamed blob = %q, %v", data, err)
+	}
+
+	// Errors contain the response, without the token.
+	_, err = vis.GetBlob(server.URL + "/api/v4/projects/group%2Fname/repository/blobs/missing/raw")
+	if err == nil || !strings.Contains(err.Error(), "404 Blob Not Found") {
+		t.Errorf("error = %v, want the response message", err)
+	}
+}
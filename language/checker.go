@@ -0,0 +1,9 @@
+package language
+
+// Checker checks texts for errors. LanguageTool is a Checker backed by LanguageTool servers.
+type Checker interface {
+	// CheckWithOptions checks the text of the options.
+	CheckWithOptions(opts *CheckOptions) (*CheckResult, error)
+	// Languages returns the languages supported by the Checker.
+	Languages() (*LanguagesResult, error)
+}
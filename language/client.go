@@ -0,0 +1,97 @@
+package language
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultTimeout is the default timeout of a request, including reading the response.
+	DefaultTimeout = 30 * time.Second
+	// DefaultRetries is the default number of retries on server errors.
+	DefaultRetries = 3
+	// DefaultBackoff is the default backoff before the first retry.
+	DefaultBackoff = 500 * time.Millisecond
+)
+
+// NewClient returns a client which keeps a pool of connections alive for concurrent requests.
+func NewClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout:   10 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 32,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
+
+// do sends a request created for a server and returns the response body. The servers are used in
+// turn, so that a failed request is retried on the next server. Network errors, 5xx and 429
+// responses are retried with exponential backoff, while other responses are returned as is.
+func (lt *LanguageTool) do(newRequest func(addr string) (*http.Request, error)) ([]byte, error) {
+	client := lt.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	addrs := lt.Addrs
+	if len(addrs) == 0 {
+		addrs = []string{lt.Addr}
+	}
+
+	start := int(atomic.AddUint32(&lt.next, 1) - 1)
+	backoff := lt.Backoff
+	var lastErr error
+	for attempt := 0; attempt <= lt.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		addr := addrs[(start+attempt)%len(addrs)]
+		req, err := newRequest(addr)
+		if err != nil {
+			return nil, fmt.Errorf("error on creating new request: %s", err)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = fmt.Errorf("error on reading a response from %s: %s", addr, err)
+			continue
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			lastErr = fmt.Errorf("server %s responded %s: %s", addr, resp.Status, body)
+			// Wait at least as long as the server asks to.
+			if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+				if wait := time.Duration(seconds) * time.Second; wait > backoff {
+					backoff = wait
+				}
+			}
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("server %s responded %s: %s", addr, resp.Status, body)
+		}
+
+		return body, nil
+	}
+
+	return nil, fmt.Errorf("giving up after %d attempts: %s", lt.Retries+1, lastErr)
+}
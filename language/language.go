@@ -3,15 +3,28 @@ package language
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 )
 
 // LanguageTool is for visiting languagetool API.
 type LanguageTool struct {
 	// Addr represents the address of languagetool server.
 	Addr string
+	// Addrs represents the addresses of all languagetool servers, including Addr. Requests are
+	// balanced across the servers and fail over to the next server on errors.
+	Addrs []string
+	// Client for sending requests, which keeps connections to the servers alive.
+	Client *http.Client
+	// Number of retries on server errors before giving up.
+	Retries int
+	// Backoff before the first retry, which doubles for each subsequent retry.
+	Backoff time.Duration
+
+	// Counter for balancing requests across the servers.
+	next uint32
 }
 
 // CheckResult is the response of check request.
@@ -48,6 +61,28 @@ type Language struct {
 	Name string `json:"name"`
 	// ISO 639-1 code like "en", "en-US", or "ca-ES-valencia".
 	Code string `json:"code"`
+	// Full code like "en-US", only returned by languages request.
+	LongCode string `json:"longCode,omitempty"`
+	// The language detected from the text, if it is detected automatically.
+	DetectedLanguage *DetectedLanguage `json:"detectedLanguage,omitempty"`
+}
+
+// DetectedLanguage is the language detected from the text.
+type DetectedLanguage struct {
+	// Language name.
+	Name string `json:"name"`
+	// ISO 639-1 code like "en", "en-US", or "ca-ES-valencia".
+	Code string `json:"code"`
+	// Confidence of the detection between 0 and 1.
+	Confidence float64 `json:"confidence"`
+}
+
+// Detected returns the code of the language which the text is checked in.
+func (cr *CheckResult) Detected() string {
+	if cr.Language.DetectedLanguage != nil && cr.Language.DetectedLanguage.Code != "" {
+		return cr.Language.DetectedLanguage.Code
+	}
+	return cr.Language.Code
 }
 
 // Match represents an error in the text.
@@ -70,6 +105,20 @@ type Match struct {
 	Rule Rule `json:"rule"`
 }
 
+// UTF16Len returns the number of UTF-16 code units of the text, which is how LanguageTool counts
+// characters in the offsets and lengths of matches.
+func UTF16Len(text string) int {
+	n := 0
+	for _, r := range text {
+		if r >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
 // Replacement that might correct the error.
 type Replacement struct {
 	// The replacement string.
@@ -119,23 +168,51 @@ type Category struct {
 // LanguagesResult is the response of languages request.
 type LanguagesResult []Language
 
+// Supports returns whether a language code like "en" or "en-US" is supported.
+func (lr LanguagesResult) Supports(code string) bool {
+	for _, lang := range lr {
+		if strings.EqualFold(lang.Code, code) || strings.EqualFold(lang.LongCode, code) {
+			return true
+		}
+	}
+	return false
+}
+
 // NewLanguageTool returns a LanguageTool with an error if necessary.
 func NewLanguageTool(host string, port string) (*LanguageTool, error) {
 	if host == "" {
 		return nil, fmt.Errorf("cannot use an empty host")
 	}
 	if port == "" {
-		return &LanguageTool{
-			Addr: host,
-		}, nil
+		return NewLanguageToolCluster([]string{host})
+	}
+
+	return NewLanguageToolCluster([]string{host + ":" + port})
+}
+
+// NewLanguageToolCluster returns a LanguageTool visiting several servers with an error if necessary.
+func NewLanguageToolCluster(addrs []string) (*LanguageTool, error) {
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("cannot use an empty server list")
+	}
+	for _, addr := range addrs {
+		if addr == "" {
+			return nil, fmt.Errorf("cannot use an empty host")
+		}
 	}
 
 	return &LanguageTool{
-		Addr: host + ":" + port,
+		Addr:    addrs[0],
+		Addrs:   addrs,
+		Client:  NewClient(DefaultTimeout),
+		Retries: DefaultRetries,
+		Backoff: DefaultBackoff,
 	}, nil
 }
 
 // NewCheckBody returns a body for check request.
+//
+// Deprecated: use CheckOptions.Values instead, which supports all parameters of check request.
 func (lt *LanguageTool) NewCheckBody(
 	text string,
 	language string,
@@ -149,39 +226,24 @@ func (lt *LanguageTool) NewCheckBody(
 	if text == "" {
 		return nil, fmt.Errorf("missing text parameter")
 	}
-	if language == "" {
-		return nil, fmt.Errorf("missing language parameter")
-	}
 
-	strFunc := func(s string) []string {
-		if s == "" {
-			return nil
-		}
-		return []string{s}
+	opts := &CheckOptions{
+		Text:               text,
+		Language:           language,
+		MotherTongue:       motherTongue,
+		PreferredVariants:  preferredVariants,
+		EnabledRules:       enabledRules,
+		DisabledRules:      disabledRules,
+		EnabledCategories:  enabledCategories,
+		DisabledCategories: disabledCategories,
+		EnabledOnly:        enabledOnly,
 	}
-	boolFunc := func(b bool) []string {
-		if b == true {
-			return []string{"true"}
-		}
-		return []string{"false"}
-	}
-
-	cb := url.Values{
-		"text":               {text},
-		"language":           {language},
-		"motherTongue":       strFunc(motherTongue),
-		"preferredVariants":  strFunc(preferredVariants),
-		"enabledRules":       strFunc(enabledRules),
-		"disabledRules":      strFunc(disabledRules),
-		"enabledCategories":  strFunc(enabledCategories),
-		"disabledCategories": strFunc(disabledCategories),
-		"enabledOnly":        boolFunc(enabledOnly),
-	}
-
-	return cb, nil
+	return opts.values()
 }
 
 // Check requests the check API.
+//
+// Deprecated: use CheckWithOptions instead, which supports all parameters of check request.
 func (lt *LanguageTool) Check(
 	text string,
 	language string,
@@ -192,29 +254,45 @@ func (lt *LanguageTool) Check(
 	enabledCategories string,
 	disabledCategories string,
 	enabledOnly bool) (*CheckResult, error) {
-	cb, err := lt.NewCheckBody(
-		text,
-		language,
-		motherTongue,
-		preferredVariants,
-		enabledRules,
-		disabledRules,
-		enabledCategories,
-		disabledCategories,
-		enabledOnly)
+	opts := &CheckOptions{
+		Text:               text,
+		Language:           language,
+		MotherTongue:       motherTongue,
+		PreferredVariants:  preferredVariants,
+		EnabledRules:       enabledRules,
+		DisabledRules:      disabledRules,
+		EnabledCategories:  enabledCategories,
+		DisabledCategories: disabledCategories,
+		EnabledOnly:        enabledOnly,
+	}
+	cb, err := opts.values()
 	if err != nil {
 		return nil, fmt.Errorf("error on creating new check body: %s", err)
 	}
+	return lt.check(cb)
+}
 
-	resp, err := http.PostForm(lt.GetURL("", lt.Addr, "/v2/check"), cb)
+// CheckWithOptions requests the check API with the options.
+func (lt *LanguageTool) CheckWithOptions(opts *CheckOptions) (*CheckResult, error) {
+	cb, err := opts.Values()
 	if err != nil {
-		return nil, fmt.Errorf("error on requesting a check: %s", err)
+		return nil, fmt.Errorf("error on creating new check body: %s", err)
 	}
-	defer resp.Body.Close()
+	return lt.check(cb)
+}
 
-	body, err := ioutil.ReadAll(resp.Body)
+// check requests the check API with a check body.
+func (lt *LanguageTool) check(cb url.Values) (*CheckResult, error) {
+	body, err := lt.do(func(addr string) (*http.Request, error) {
+		req, err := http.NewRequest("POST", lt.GetURL("", addr, "/v2/check"), strings.NewReader(cb.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error on reading a check response: %s", err)
+		return nil, fmt.Errorf("error on requesting a check: %s", err)
 	}
 
 	cr := new(CheckResult)
@@ -228,22 +306,12 @@ func (lt *LanguageTool) Check(
 
 // Languages request the languages API.
 func (lt *LanguageTool) Languages() (*LanguagesResult, error) {
-	client := &http.Client{}
-	req, err := http.NewRequest("GET", lt.GetURL("", lt.Addr, "/v2/languages"), nil)
-	if err != nil {
-		return nil, fmt.Errorf("error on creating new request: %s", err)
-	}
-
-	resp, err := client.Do(req)
+	body, err := lt.do(func(addr string) (*http.Request, error) {
+		return http.NewRequest("GET", lt.GetURL("", addr, "/v2/languages"), nil)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("error on requesting languages: %s", err)
 	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error on reading languages response: %s", err)
-	}
 
 	lr := new(LanguagesResult)
 	err = json.Unmarshal(body, lr)
@@ -254,8 +322,12 @@ func (lt *LanguageTool) Languages() (*LanguagesResult, error) {
 	return lr, nil
 }
 
-// GetURL returns the url for sending requests.
+// GetURL returns the url for sending requests. The host may contain a scheme, like
+// "https://api.languagetool.org".
 func (lt *LanguageTool) GetURL(scheme string, host string, path string) string {
+	if i := strings.Index(host, "://"); i >= 0 {
+		scheme, host = host[:i], host[i+3:]
+	}
 	if scheme == "" {
 		scheme = "http"
 	}
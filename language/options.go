@@ -0,0 +1,134 @@
+package language
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// AutoLanguage makes LanguageTool detect the language of the text.
+const AutoLanguage = "auto"
+
+const (
+	// LevelDefault checks the text with the default rules.
+	LevelDefault = "default"
+	// LevelPicky additionally checks the text with rules for formal text.
+	LevelPicky = "picky"
+)
+
+// CheckOptions contains the parameters of check request.
+type CheckOptions struct {
+	// The text to be checked. Either Text or Data is required.
+	Text string
+	// The text to be checked as annotated markup. Either Text or Data is required.
+	Data *AnnotatedText
+	// A language code like "en-US", or "auto" for detecting the language automatically.
+	Language string
+	// Username for the Premium API, which requires APIKey as well.
+	Username string
+	// API key for the Premium API, which requires Username as well.
+	APIKey string
+	// Comma-separated names of the user dictionaries to use, which requires Username and APIKey.
+	Dicts string
+	// A language code of the user's native language, enabling false friends checks.
+	MotherTongue string
+	// Comma-separated language variants to prefer, like "en-GB,de-AT", when Language is "auto".
+	PreferredVariants string
+	// Comma-separated language codes the text may be written in, when Language is "auto".
+	PreferredLanguages string
+	// Comma-separated IDs of rules to be enabled.
+	EnabledRules string
+	// Comma-separated IDs of rules to be disabled.
+	DisabledRules string
+	// Comma-separated IDs of categories to be enabled.
+	EnabledCategories string
+	// Comma-separated IDs of categories to be disabled.
+	DisabledCategories string
+	// Whether only the rules and categories enabled above are used.
+	EnabledOnly bool
+	// LevelDefault or LevelPicky. If empty, the server decides.
+	Level string
+}
+
+// AnnotatedText is text with markup, where only the text parts are checked. Offsets of the matches
+// refer to the whole text, including the markup.
+type AnnotatedText struct {
+	// Parts of the annotated text.
+	Annotation []Annotation `json:"annotation"`
+}
+
+// Annotation is a part of an annotated text, which is either text or markup.
+type Annotation struct {
+	// Text to be checked.
+	Text string `json:"text,omitempty"`
+	// Markup to be skipped.
+	Markup string `json:"markup,omitempty"`
+	// Text the markup is interpreted as, like "\n\n" for a paragraph break.
+	InterpretAs string `json:"interpretAs,omitempty"`
+}
+
+// Values returns a body for check request.
+func (opts *CheckOptions) Values() (url.Values, error) {
+	if opts.EnabledOnly && opts.EnabledRules == "" && opts.EnabledCategories == "" {
+		return nil, fmt.Errorf("enabledOnly parameter requires enabled rules or categories")
+	}
+	return opts.values()
+}
+
+// values returns a body for check request without requiring enabled rules or categories for the
+// enabledOnly parameter, which the deprecated wrappers have always accepted.
+func (opts *CheckOptions) values() (url.Values, error) {
+	if opts.Text == "" && opts.Data == nil {
+		return nil, fmt.Errorf("missing text or data parameter")
+	}
+	if opts.Text != "" && opts.Data != nil {
+		return nil, fmt.Errorf("cannot use both text and data parameters")
+	}
+	if opts.Language == "" {
+		return nil, fmt.Errorf("missing language parameter")
+	}
+	if (opts.Username == "") != (opts.APIKey == "") {
+		return nil, fmt.Errorf("username and apiKey parameters must be used together")
+	}
+	if opts.Dicts != "" && opts.Username == "" {
+		return nil, fmt.Errorf("dicts parameter requires username and apiKey parameters")
+	}
+	if opts.Level != "" && opts.Level != LevelDefault && opts.Level != LevelPicky {
+		return nil, fmt.Errorf("invalid level parameter %s", opts.Level)
+	}
+
+	cb := url.Values{}
+	set := func(key string, value string) {
+		if value != "" {
+			cb.Set(key, value)
+		}
+	}
+
+	set("text", opts.Text)
+	if opts.Data != nil {
+		data, err := json.Marshal(opts.Data)
+		if err != nil {
+			return nil, fmt.Errorf("error on encoding data parameter: %s", err)
+		}
+		set("data", string(data))
+	}
+	set("language", opts.Language)
+	set("username", opts.Username)
+	set("apiKey", opts.APIKey)
+	set("dicts", opts.Dicts)
+	set("motherTongue", opts.MotherTongue)
+	set("preferredVariants", opts.PreferredVariants)
+	set("preferredLanguages", opts.PreferredLanguages)
+	set("enabledRules", opts.EnabledRules)
+	set("disabledRules", opts.DisabledRules)
+	set("enabledCategories", opts.EnabledCategories)
+	set("disabledCategories", opts.DisabledCategories)
+	set("level", opts.Level)
+	if opts.EnabledOnly {
+		cb.Set("enabledOnly", "true")
+	} else {
+		cb.Set("enabledOnly", "false")
+	}
+
+	return cb, nil
+}
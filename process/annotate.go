@@ -0,0 +1,53 @@
+package process
+
+import (
+	"regexp"
+
+	"github.com/huangjiuyuan/typospider/language"
+)
+
+// Patterns of the markup in comments, which are not checked as prose.
+var (
+	// Comment markers, like "//", "/*", "*/" and leading "*" in block comments.
+	markerExp = `//+|/\*+|\*+/|(?m:^[ \t]*\*+)`
+	// Code spans quoted by backticks.
+	codeExp = "`[^`\n]*`"
+	// URLs.
+	urlExp = `[a-zA-Z][a-zA-Z0-9+.-]*://[^\s<>"')\]]*[^\s<>"')\].,;:!?]`
+	// Qualified identifiers like "os.Exit", and function calls like "Open()".
+	qualifiedExp = `\b[A-Za-z_]\w*(\.[A-Za-z_]\w+)+(\(\))?|\b[A-Za-z_]\w*\(\)`
+	// Identifiers in camel case, in snake case, or with digits.
+	identExp = `\b[A-Za-z]\w*[a-z0-9][A-Z]\w*\b|\b\w+_\w+\b|\b[A-Za-z]+[0-9]\w*\b`
+	// Format verbs in string literals, like "%s" and "%-10.2f".
+	verbExp = `%[-+#0]*(\d+|\*)?(\.(\d+|\*))?[a-zA-Z]`
+
+	markupExp = regexp.MustCompile(markerExp + "|" + codeExp + "|" + urlExp + "|" + qualifiedExp + "|" + identExp + "|" + verbExp)
+	// Markers are skipped silently, while code and URLs are read as a noun to keep sentences intact.
+	silentExp = regexp.MustCompile(`^(` + markerExp + `)$`)
+)
+
+// Annotate splits a text into prose and markup, so that comment markers, code spans, identifiers,
+// URLs and format verbs are not checked. The parts joined together are exactly the text, so that
+// offsets of matches still refer to the text.
+func Annotate(text string) []language.Annotation {
+	annotations := []language.Annotation{}
+	last := 0
+	for _, loc := range markupExp.FindAllStringIndex(text, -1) {
+		if loc[0] > last {
+			annotations = append(annotations, language.Annotation{Text: text[last:loc[0]]})
+		}
+
+		markup := text[loc[0]:loc[1]]
+		if silentExp.MatchString(markup) {
+			annotations = append(annotations, language.Annotation{Markup: markup})
+		} else {
+			annotations = append(annotations, language.Annotation{Markup: markup, InterpretAs: codePlaceholder})
+		}
+		last = loc[1]
+	}
+	if last < len(text) {
+		annotations = append(annotations, language.Annotation{Text: text[last:]})
+	}
+
+	return annotations
+}
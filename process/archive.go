@@ -0,0 +1,96 @@
+package process
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/huangjiuyuan/typospider/github"
+)
+
+// ProcessArchive wraps the archive processing function.
+func (proc *Processer) ProcessArchive(owner string, repo string, ref string) {
+	err := proc.processArchive(owner, repo, ref, "")
+	if err != nil {
+		fmt.Printf("[Error] Processing archive failed: %s\n", err)
+	}
+
+	// Send a signal that no more blob is produced.
+	proc.Finish()
+}
+
+// processArchive downloads the tarball of a ref in a repository, and enqueues the blobs accepted to
+// the blob queue with their data, so that no request is sent per file. The tarball is streamed rather
+// than the zipball, whose entries cannot be read before the whole archive is downloaded. The SHAs of
+// the blobs are computed locally. Submodules are not included in tarballs. It blocks while the blobs
+// waiting in the queue hold MaxQueuedBytes.
+func (proc *Processer) processArchive(owner string, repo string, ref string, name string) error {
+	body, err := proc.Visitor.GetTarball(proc.Visitor.GetTarballURL(owner, repo, ref))
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	gz, err := gzip.NewReader(body)
+	if err != nil {
+		return fmt.Errorf("error on reading tarball: %s", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("error on reading tarball: %s", err)
+		}
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeSymlink {
+			continue
+		}
+
+		// Entries are under a directory named after the repository and the commit, like
+		// "owner-name-sha/".
+		parts := strings.SplitN(hdr.Name, "/", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		path := parts[1]
+		if !proc.Accepts(path) {
+			continue
+		}
+		if hdr.Typeflag == tar.TypeSymlink {
+			proc.skip(name, path, SkipSymlink)
+			continue
+		}
+		if proc.tooLarge(int(hdr.Size)) {
+			proc.skip(name, path, SkipLarge)
+			continue
+		}
+
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return fmt.Errorf("error on reading %s in tarball: %s", path, err)
+		}
+		sha := github.BlobSHA(data)
+		mode := github.ModeFile
+		if hdr.Mode&0111 != 0 {
+			mode = github.ModeExecutable
+		}
+		proc.enqueueData(&github.Blob{
+			Path: path,
+			Size: len(data),
+			SHA:  sha,
+			Mode: mode,
+			URL:  proc.Visitor.GetBlobURL(owner, repo, sha),
+			Repo: name,
+			Data: &data,
+		})
+	}
+
+	return nil
+}
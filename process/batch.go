@@ -0,0 +1,115 @@
+package process
+
+import (
+	"github.com/huangjiuyuan/typospider/language"
+)
+
+// DefaultBatchSize is the default number of characters checked in one request. It is the maximum
+// text length accepted by the public LanguageTool API.
+const DefaultBatchSize = 20000
+
+// DefaultBlobBatchSize is the default number of blobs fetched per request from providers getting blobs
+// in bulk, which keeps the GraphQL queries of GitHub within their timeouts.
+const DefaultBlobBatchSize = 50
+
+// Separator of tokens in a batch. Each token is checked as a paragraph, so that no match spans two
+// tokens.
+const separator = "\n\n"
+
+// batch contains the text of several tokens which is checked in one request.
+type batch struct {
+	// Text of the tokens joined by separators.
+	text string
+	// Number of characters of the text, in UTF-16 code units.
+	size int
+	// Tokens in the batch.
+	tokens []*Token
+	// Offset of each token in the text, in UTF-16 code units.
+	offsets []int
+}
+
+// batchTokens packs the tokens into batches no longer than size characters. A token longer than
+// size is put into a batch alone.
+func batchTokens(tokens []*Token, size int) []*batch {
+	batches := []*batch{}
+	var b *batch
+	for _, token := range tokens {
+		n := language.UTF16Len(token.Text)
+		if b != nil && b.size+len(separator)+n > size {
+			b = nil
+		}
+		if b == nil {
+			b = &batch{}
+			batches = append(batches, b)
+		} else {
+			b.text += separator
+			b.size += len(separator)
+		}
+
+		b.tokens = append(b.tokens, token)
+		b.offsets = append(b.offsets, b.size)
+		b.text += token.Text
+		b.size += n
+	}
+	return batches
+}
+
+// annotated returns the text of the batch as annotated text, where only the prose is checked.
+func (b *batch) annotated() *language.AnnotatedText {
+	data := &language.AnnotatedText{}
+	for i, token := range b.tokens {
+		if i > 0 {
+			data.Annotation = append(data.Annotation, language.Annotation{Text: separator})
+		}
+		data.Annotation = append(data.Annotation, Annotate(token.Text)...)
+	}
+	return data
+}
+
+// split assigns the matches of the batch text back to the tokens, so that the offset and context of
+// each match are relative to its token.
+func (b *batch) split(matches []*language.Match) [][]*language.Match {
+	result := make([][]*language.Match, len(b.tokens))
+	for _, match := range matches {
+		// Find the last token which starts before the match.
+		i := len(b.offsets) - 1
+		for i > 0 && b.offsets[i] > match.Offset {
+			i--
+		}
+
+		match.Offset -= b.offsets[i]
+		after := language.UTF16Len(b.tokens[i].Text) - match.Offset - match.Length
+		match.Context = clipContext(match.Context, match.Offset, after)
+		result[i] = append(result[i], match)
+	}
+	return result
+}
+
+// clipContext removes the text of other tokens from the context of a match, by keeping at most the
+// given numbers of characters before and after the error, which are the characters of its token
+// around the error. The context is then the same as if the token were checked alone, wherever other
+// tokens start.
+func clipContext(ctx language.Context, before int, after int) language.Context {
+	start := byteOffset(ctx.Text, ctx.Offset)
+	end := start + byteOffset(ctx.Text[start:], ctx.Length)
+	head, tail := ctx.Text[:start], ctx.Text[end:]
+
+	if before < 0 {
+		before = 0
+	}
+	if after < 0 {
+		after = 0
+	}
+	if n := language.UTF16Len(head); n > before {
+		head = head[byteOffset(head, n-before):]
+	}
+	if language.UTF16Len(tail) > after {
+		tail = tail[:byteOffset(tail, after)]
+	}
+
+	return language.Context{
+		Text:   head + ctx.Text[start:end] + tail,
+		Offset: language.UTF16Len(head),
+		Length: ctx.Length,
+	}
+}
@@ -0,0 +1,81 @@
+package process
+
+import (
+	"testing"
+
+	"github.com/huangjiuyuan/typospider/language"
+)
+
+func TestSplit(t *testing.T) {
+	// Emojis are surrogate pairs, which are two characters for LanguageTool.
+	tokens := []*Token{{Text: "a😀 teh"}, {Text: "teh 😀b"}, {Text: "the 😀 ned"}}
+	batches := batchTokens(tokens, 1000)
+	if len(batches) != 1 {
+		t.Fatalf("tokens are packed into %d batches, want 1", len(batches))
+	}
+	b := batches[0]
+	if b.text != "a😀 teh\n\nteh 😀b\n\nthe 😀 ned" || b.size != 28 {
+		t.Fatalf("batch text = %q of %d characters", b.text, b.size)
+	}
+
+	tests := []struct {
+		name  string
+		match language.Match
+		// Index of the token of the match.
+		token int
+		want  language.Match
+	}{
+		{
+			name:  "first token",
+			match: language.Match{Offset: 4, Length: 3, Context: language.Context{Text: b.text, Offset: 4, Length: 3}},
+			token: 0,
+			want:  language.Match{Offset: 4, Length: 3, Context: language.Context{Text: "a😀 teh", Offset: 4, Length: 3}},
+		},
+		{
+			// The context starts inside the first token and ends inside the last token.
+			name:  "middle token",
+			match: language.Match{Offset: 9, Length: 3, Context: language.Context{Text: "😀 teh\n\nteh 😀b\n\nthe", Offset: 8, Length: 3}},
+			token: 1,
+			want:  language.Match{Offset: 0, Length: 3, Context: language.Context{Text: "teh 😀b", Offset: 0, Length: 3}},
+		},
+		{
+			name:  "last token",
+			match: language.Match{Offset: 25, Length: 3, Context: language.Context{Text: "😀b\n\nthe 😀 ned", Offset: 12, Length: 3}},
+			token: 2,
+			want:  language.Match{Offset: 7, Length: 3, Context: language.Context{Text: "the 😀 ned", Offset: 7, Length: 3}},
+		},
+	}
+
+	for _, test := range tests {
+		match := test.match
+		result := b.split([]*language.Match{&match})
+		if len(result[test.token]) != 1 {
+			t.Errorf("%s: match is assigned to %v, want token %d", test.name, result, test.token)
+			continue
+		}
+		got := result[test.token][0]
+		if got.Offset != test.want.Offset || got.Length != test.want.Length || got.Context != test.want.Context {
+			t.Errorf("%s: match = %+v, want %+v", test.name, *got, test.want)
+		}
+	}
+}
+
+func TestClipContext(t *testing.T) {
+	ctx := language.Context{Text: "x😀 teh 😀y", Offset: 4, Length: 3}
+	tests := []struct {
+		before int
+		after  int
+		want   language.Context
+	}{
+		{before: 10, after: 10, want: ctx},
+		{before: 3, after: 3, want: language.Context{Text: "😀 teh 😀", Offset: 3, Length: 3}},
+		{before: 0, after: 0, want: language.Context{Text: "teh", Offset: 0, Length: 3}},
+		{before: -1, after: 1, want: language.Context{Text: "teh ", Offset: 0, Length: 3}},
+	}
+
+	for _, test := range tests {
+		if got := clipContext(ctx, test.before, test.after); got != test.want {
+			t.Errorf("clipContext(%d, %d) = %+v, want %+v", test.before, test.after, got, test.want)
+		}
+	}
+}
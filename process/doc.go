@@ -0,0 +1,224 @@
+package process
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/huangjiuyuan/typospider/language"
+)
+
+const (
+	// KindPackageDoc is the kind of tokens of package doc comments.
+	KindPackageDoc = "package doc"
+	// KindDoc is the kind of tokens of doc comments on declarations.
+	KindDoc = "doc comment"
+	// KindLine is the kind of tokens of comments following a declaration on the same line.
+	KindLine = "line comment"
+
+	// DocRuleID is the identifier of the rule that a doc comment starts with the declared name.
+	DocRuleID = "DOC_COMMENT_NAME"
+)
+
+// Extractor extracts the tokens to be checked from the text of a file.
+type Extractor interface {
+	Extract(path string, text string) ([]*Token, error)
+}
+
+// Extract extracts the comments of a file with a Go-like syntax.
+func (tokenizer *Tokenizer) Extract(path string, text string) ([]*Token, error) {
+	return tokenizer.Tokenize(text)
+}
+
+// GoExtractor extracts the comments of a Go file by parsing its syntax tree, so that each comment is
+// associated with the declaration it documents.
+type GoExtractor struct{}
+
+// NewGoExtractor returns a GoExtractor with an error if necessary.
+func NewGoExtractor() (*GoExtractor, error) {
+	return &GoExtractor{}, nil
+}
+
+// Extract extracts the comment groups of a Go file. Doc comments are associated with the package or
+// the declarations they document, and other comments are of kind KindComment.
+func (ext *GoExtractor) Extract(path string, text string) ([]*Token, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, text, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := []*Token{}
+	seen := make(map[*ast.CommentGroup]bool)
+	add := func(group *ast.CommentGroup, kind string, object string, name string) {
+		if group == nil || seen[group] {
+			return
+		}
+		seen[group] = true
+		tok := commentToken(fset, group, kind)
+		tok.Object, tok.Name = object, name
+		tokens = append(tokens, tok)
+	}
+
+	add(f.Doc, KindPackageDoc, "package "+f.Name.Name, f.Name.Name)
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			object := "func " + d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				object = "method " + receiverName(d.Recv.List[0].Type) + "." + d.Name.Name
+			}
+			add(d.Doc, KindDoc, object, d.Name.Name)
+		case *ast.GenDecl:
+			// A doc comment of a declaration with a single spec documents the spec.
+			if len(d.Specs) == 1 && d.Lparen == token.NoPos {
+				object, name := specName(d.Tok, d.Specs[0])
+				add(d.Doc, KindDoc, object, name)
+			} else {
+				add(d.Doc, KindDoc, d.Tok.String()+" block", "")
+			}
+			for _, spec := range d.Specs {
+				object, name := specName(d.Tok, spec)
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					add(spec.Doc, KindDoc, object, name)
+					add(spec.Comment, KindLine, object, name)
+					addMembers(spec, add)
+				case *ast.ValueSpec:
+					add(spec.Doc, KindDoc, object, name)
+					add(spec.Comment, KindLine, object, name)
+				}
+			}
+		}
+	}
+
+	// Comments which are not associated with any declaration, like those in function bodies.
+	for _, group := range f.Comments {
+		add(group, KindComment, "", "")
+	}
+
+	sort.SliceStable(tokens, func(i, j int) bool {
+		return tokens[i].Segments[0].Position < tokens[j].Segments[0].Position
+	})
+	return tokens, nil
+}
+
+// addMembers adds the comments of the fields of a struct type or the methods of an interface type.
+func addMembers(spec *ast.TypeSpec, add func(*ast.CommentGroup, string, string, string)) {
+	var list *ast.FieldList
+	kind := "field"
+	switch t := spec.Type.(type) {
+	case *ast.StructType:
+		list = t.Fields
+	case *ast.InterfaceType:
+		list, kind = t.Methods, "method"
+	default:
+		return
+	}
+
+	// Comments of members are not required to start with their names.
+	for _, field := range list.List {
+		object := kind + " " + spec.Name.Name
+		if len(field.Names) > 0 {
+			object += "." + field.Names[0].Name
+		}
+		add(field.Doc, KindDoc, object, "")
+		add(field.Comment, KindLine, object, "")
+	}
+}
+
+// specName returns the description and the name of a spec, like "type Foo" and "Foo".
+func specName(tok token.Token, spec ast.Spec) (string, string) {
+	switch spec := spec.(type) {
+	case *ast.TypeSpec:
+		return "type " + spec.Name.Name, spec.Name.Name
+	case *ast.ValueSpec:
+		names := []string{}
+		for _, name := range spec.Names {
+			names = append(names, name.Name)
+		}
+		name := ""
+		if len(spec.Names) == 1 {
+			name = spec.Names[0].Name
+		}
+		return tok.String() + " " + strings.Join(names, ", "), name
+	case *ast.ImportSpec:
+		return "import " + spec.Path.Value, ""
+	}
+	return tok.String(), ""
+}
+
+// commentToken returns a token of a comment group, where the comments are joined by spaces.
+func commentToken(fset *token.FileSet, group *ast.CommentGroup, kind string) *Token {
+	tok := &Token{
+		Line: fset.Position(group.Pos()).Line,
+		Kind: kind,
+	}
+	for i, c := range group.List {
+		if i > 0 {
+			tok.Add(" ", -1)
+		}
+		tok.Add(c.Text, fset.Position(c.Pos()).Offset)
+	}
+	return tok
+}
+
+// checkDoc returns a match if the doc comment of an exported declaration does not start with the
+// declared name, optionally preceded by an article, as recommended by Effective Go.
+func checkDoc(token *Token) *language.Match {
+	if token.Kind != KindDoc || token.Name == "" || !ast.IsExported(token.Name) {
+		return nil
+	}
+
+	// Find the first word after the comment markers.
+	start := strings.IndexFunc(token.Text, func(r rune) bool {
+		return r != '/' && r != '*' && !unicode.IsSpace(r)
+	})
+	if start < 0 {
+		return nil
+	}
+	fields := strings.Fields(token.Text[start:])
+	if len(fields) == 0 {
+		return nil
+	}
+	first := fields[0]
+	if first == token.Name || strings.HasPrefix(first, token.Name+".") || strings.HasPrefix(first, token.Name+",") {
+		return nil
+	}
+	if (first == "A" || first == "An" || first == "The") && len(fields) > 1 && strings.Trim(fields[1], ".,") == token.Name {
+		return nil
+	}
+	// Directives and deprecation notices are not sentences.
+	if strings.HasPrefix(first, "Deprecated:") || strings.HasPrefix(token.Text, "//go:") {
+		return nil
+	}
+
+	short := "Doc comment should start with the name"
+	category, name := "STYLE", "Style"
+	ctxLen := language.UTF16Len(first)
+	return &language.Match{
+		Message:      fmt.Sprintf("Doc comment on %s should start with \"%s\".", token.Object, token.Name),
+		ShortMessage: &short,
+		Offset:       language.UTF16Len(token.Text[:start]),
+		Length:       ctxLen,
+		Replacements: []*language.Replacement{},
+		Context: language.Context{
+			Text:   token.Text,
+			Offset: language.UTF16Len(token.Text[:start]),
+			Length: ctxLen,
+		},
+		Sentence: token.Text,
+		Rule: language.Rule{
+			ID:          DocRuleID,
+			Description: "Doc comment should start with the declared name",
+			Category: language.Category{
+				ID:   &category,
+				Name: &name,
+			},
+		},
+	}
+}
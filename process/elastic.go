@@ -2,7 +2,9 @@ package process
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 
 	"github.com/olivere/elastic"
 )
@@ -24,6 +26,15 @@ const fileMapping = `
                 "url":{
                     "type":"text"
                 },
+                "repo":{
+                    "type":"keyword"
+                },
+                "encoding":{
+                    "type":"keyword"
+                },
+                "omitted":{
+                    "type":"boolean"
+                },
                 "fragments":{
                     "type":"nested",
                     "properties":{
@@ -135,6 +146,90 @@ const typoMapping = `
                         }
                     }
                 },
+                "valid":{
+                    "type":"boolean"
+                },
+                "status":{
+                    "type":"keyword"
+                },
+                "reason":{
+                    "type":"text"
+                },
+                "author":{
+                    "type":"keyword"
+                },
+                "path":{
+                    "type":"keyword"
+                },
+                "line":{
+                    "type":"integer"
+                },
+                "position":{
+                    "type":"integer"
+                },
+                "length":{
+                    "type":"integer"
+                },
+                "text":{
+                    "type":"keyword"
+                },
+                "language":{
+                    "type":"keyword"
+                },
+                "kind":{
+                    "type":"keyword"
+                },
+                "object":{
+                    "type":"keyword"
+                },
+                "repo":{
+                    "type":"keyword"
+                }
+            }
+        }
+    }
+}`
+
+const identifierMapping = `
+{
+    "mappings":{
+        "identifier":{
+            "properties":{
+                "sha":{
+                    "type":"keyword"
+                },
+                "fileId":{
+                    "type":"keyword"
+                },
+                "path":{
+                    "type":"keyword"
+                },
+                "line":{
+                    "type":"integer"
+                },
+                "name":{
+                    "type":"keyword"
+                },
+                "kind":{
+                    "type":"keyword"
+                },
+                "exported":{
+                    "type":"boolean"
+                },
+                "breaking":{
+                    "type":"boolean"
+                },
+                "misspellings":{
+                    "type":"nested",
+                    "properties":{
+                        "word":{
+                            "type":"keyword"
+                        },
+                        "suggestions":{
+                            "type":"keyword"
+                        }
+                    }
+                },
                 "valid":{
                     "type":"boolean"
                 }
@@ -148,10 +243,11 @@ type Elastic struct {
 	Version    string
 	Initialize bool
 
-	fileMapping string
-	typoMapping string
-	ctx         context.Context
-	client      *elastic.Client
+	fileMapping       string
+	typoMapping       string
+	identifierMapping string
+	ctx               context.Context
+	client            *elastic.Client
 }
 
 func InitClient(scheme string, host string, port string, initialize bool) (*Elastic, error) {
@@ -173,59 +269,45 @@ func InitClient(scheme string, host string, port string, initialize bool) (*Elas
 		Version:    version,
 		Initialize: initialize,
 
-		fileMapping: fileMapping,
-		typoMapping: typoMapping,
-		ctx:         ctx,
-		client:      client,
+		fileMapping:       fileMapping,
+		typoMapping:       typoMapping,
+		identifierMapping: identifierMapping,
+		ctx:               ctx,
+		client:            client,
 	}, nil
 }
 
 func (es *Elastic) CreateFileIndex(index string) error {
-	if es.Initialize {
-		err := es.DeleteIndex(index)
-		if err != nil {
-			return err
-		}
-	} else {
-		exists, err := es.client.IndexExists(index).Do(es.ctx)
-		if err != nil {
-			return err
-		}
+	return es.createIndex(index, es.fileMapping)
+}
 
-		if exists {
-			return fmt.Errorf("index %s already exists", index)
-		}
-	}
+func (es *Elastic) CreateTypoIndex(index string) error {
+	return es.createIndex(index, es.typoMapping)
+}
 
-	result, err := es.client.CreateIndex(index).BodyString(es.fileMapping).Do(es.ctx)
+func (es *Elastic) CreateIdentifierIndex(index string) error {
+	return es.createIndex(index, es.identifierMapping)
+}
+
+// createIndex creates an index with the mapping. An existing index is deleted first if the Elastic
+// agent initializes indices, otherwise it is an error.
+func (es *Elastic) createIndex(index string, mapping string) error {
+	exists, err := es.client.IndexExists(index).Do(es.ctx)
 	if err != nil {
 		return err
 	}
-	if !result.Acknowledged {
-		return fmt.Errorf("index %s creation not acknowledged", index)
-	}
 
-	return nil
-}
-
-func (es *Elastic) CreateTypoIndex(index string) error {
-	if es.Initialize {
-		err := es.DeleteIndex(index)
-		if err != nil {
-			return err
+	if exists {
+		if !es.Initialize {
+			return fmt.Errorf("index %s already exists", index)
 		}
-	} else {
-		exists, err := es.client.IndexExists(index).Do(es.ctx)
+		err := es.DeleteIndex(index)
 		if err != nil {
 			return err
 		}
-
-		if exists {
-			return fmt.Errorf("index %s already exists", index)
-		}
 	}
 
-	result, err := es.client.CreateIndex(index).BodyString(es.typoMapping).Do(es.ctx)
+	result, err := es.client.CreateIndex(index).BodyString(mapping).Do(es.ctx)
 	if err != nil {
 		return err
 	}
@@ -284,12 +366,31 @@ func (es *Elastic) GetFile(index string, id string) (*elastic.GetResult, error)
 	return resp, nil
 }
 
+func (es *Elastic) LoadFile(index string, id string) (*File, error) {
+	resp, err := es.GetFile(index, id)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Found || resp.Source == nil {
+		return nil, fmt.Errorf("file %s not found", id)
+	}
+
+	file := new(File)
+	err = json.Unmarshal(*resp.Source, file)
+	if err != nil {
+		return nil, fmt.Errorf("error on parsing file %s: %s", id, err)
+	}
+
+	return file, nil
+}
+
 func (es *Elastic) UpdateFile(index string, id string, file File) (*elastic.UpdateResponse, error) {
 	resp, err := es.client.Update().
 		Index(index).
 		Type("file").
 		Id(id).
-		Upsert(file).
+		Doc(file).
+		DocAsUpsert(true).
 		Do(es.ctx)
 	if err != nil {
 		return nil, err
@@ -338,12 +439,31 @@ func (es *Elastic) GetTypo(index string, id string) (*elastic.GetResult, error)
 	return resp, nil
 }
 
+func (es *Elastic) LoadTypo(index string, id string) (*Typo, error) {
+	resp, err := es.GetTypo(index, id)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Found || resp.Source == nil {
+		return nil, fmt.Errorf("typo %s not found", id)
+	}
+
+	typo := new(Typo)
+	err = json.Unmarshal(*resp.Source, typo)
+	if err != nil {
+		return nil, fmt.Errorf("error on parsing typo %s: %s", id, err)
+	}
+
+	return typo, nil
+}
+
 func (es *Elastic) UpdateTypo(index string, id string, typo Typo) (*elastic.UpdateResponse, error) {
 	resp, err := es.client.Update().
 		Index(index).
 		Type("typo").
 		Id(id).
-		Upsert(typo).
+		Doc(typo).
+		DocAsUpsert(true).
 		Do(es.ctx)
 	if err != nil {
 		return nil, err
@@ -364,3 +484,47 @@ func (es *Elastic) DeleteTypo(index string, id string, typo Typo) (*elastic.Dele
 
 	return resp, nil
 }
+
+func (es *Elastic) SearchTypos(index string, query elastic.Query) ([]*Typo, error) {
+	scroll := es.client.Scroll(index).
+		Type("typo").
+		Query(query).
+		Size(100)
+	defer scroll.Clear(es.ctx)
+
+	typos := []*Typo{}
+	for {
+		result, err := scroll.Do(es.ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, hit := range result.Hits.Hits {
+			typo := new(Typo)
+			err := json.Unmarshal(*hit.Source, typo)
+			if err != nil {
+				return nil, err
+			}
+			typos = append(typos, typo)
+		}
+	}
+
+	return typos, nil
+}
+
+func (es *Elastic) IndexIdentifier(index string, ident Identifier) (*elastic.IndexResponse, error) {
+	resp, err := es.client.Index().
+		Index(index).
+		Type("identifier").
+		Id(ident.SHA).
+		BodyJson(ident).
+		Do(es.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,193 @@
+package process
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encodings of files which are transcoded to UTF-8 before they are checked.
+const (
+	// EncodingUTF8BOM is UTF-8 with a byte order mark, which is stripped.
+	EncodingUTF8BOM = "UTF-8 BOM"
+	// EncodingUTF16LE is little-endian UTF-16, with or without a byte order mark.
+	EncodingUTF16LE = "UTF-16LE"
+	// EncodingUTF16BE is big-endian UTF-16, with or without a byte order mark.
+	EncodingUTF16BE = "UTF-16BE"
+	// EncodingLatin1 is ISO-8859-1, which is assumed for text which is not valid UTF-8.
+	EncodingLatin1 = "ISO-8859-1"
+)
+
+// More reasons of skipping files which are not checked.
+const (
+	// SkipMinified is the reason of skipping minified files, whose lines are too long to be prose.
+	SkipMinified = "minified"
+	// SkipEncoding is the reason of skipping files in unsupported encodings, like UTF-32.
+	SkipEncoding = "unsupported encoding"
+)
+
+// Byte order marks.
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+	bomUTF32LE = []byte{0xFF, 0xFE, 0x00, 0x00}
+	bomUTF32BE = []byte{0x00, 0x00, 0xFE, 0xFF}
+)
+
+// Thresholds of minified files. Files larger than minifiedSize are minified if their average line
+// is longer than minifiedLine bytes.
+const (
+	minifiedSize = 4096
+	minifiedLine = 1000
+)
+
+// decodeText detects the encoding of the data of a file, and returns the text transcoded to UTF-8
+// with the encoding, which is empty for UTF-8. If the file is not checked, like a binary or a
+// minified file, the reason is returned instead.
+func decodeText(path string, data []byte) ([]byte, string, string) {
+	var text []byte
+	var encoding string
+	switch {
+	case isLFSPointer(data):
+		return nil, "", SkipLFS
+	case bytes.HasPrefix(data, bomUTF32LE) || bytes.HasPrefix(data, bomUTF32BE):
+		return nil, "", SkipEncoding
+	case bytes.HasPrefix(data, bomUTF8):
+		text, encoding = data[len(bomUTF8):], EncodingUTF8BOM
+	case bytes.HasPrefix(data, bomUTF16LE):
+		text, encoding = decodeUTF16(data[len(bomUTF16LE):], false), EncodingUTF16LE
+	case bytes.HasPrefix(data, bomUTF16BE):
+		text, encoding = decodeUTF16(data[len(bomUTF16BE):], true), EncodingUTF16BE
+	default:
+		encoding = sniffUTF16(data)
+		switch {
+		case encoding != "":
+			text = decodeUTF16(data, encoding == EncodingUTF16BE)
+		case isBinary(data):
+			return nil, "", SkipBinary
+		case utf8.Valid(data):
+			text = data
+		case hasControls(data):
+			return nil, "", SkipBinary
+		default:
+			text, encoding = decodeLatin1(data), EncodingLatin1
+		}
+	}
+
+	if isMinified(path, text) {
+		return nil, "", SkipMinified
+	}
+	return text, encoding, ""
+}
+
+// sniffUTF16 returns the encoding of UTF-16 data without a byte order mark, which is detected by the
+// NUL bytes of ASCII characters at either odd or even positions, or empty if it is not UTF-16.
+func sniffUTF16(data []byte) string {
+	if len(data) > sniffLength {
+		data = data[:sniffLength]
+	}
+	if len(data) < 2 || len(data)%2 != 0 {
+		return ""
+	}
+
+	even, odd := 0, 0
+	for i := 0; i < len(data); i += 2 {
+		if data[i] == 0 {
+			even++
+		}
+		if data[i+1] == 0 {
+			odd++
+		}
+	}
+	units := len(data) / 2
+	switch {
+	case odd*10 > units*4 && even == 0:
+		return EncodingUTF16LE
+	case even*10 > units*4 && odd == 0:
+		return EncodingUTF16BE
+	default:
+		return ""
+	}
+}
+
+// decodeUTF16 transcodes UTF-16 data to UTF-8. A trailing odd byte is dropped.
+func decodeUTF16(data []byte, bigEndian bool) []byte {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		} else {
+			units[i] = uint16(data[2*i+1])<<8 | uint16(data[2*i])
+		}
+	}
+	return []byte(string(utf16.Decode(units)))
+}
+
+// decodeLatin1 transcodes ISO-8859-1 data to UTF-8.
+func decodeLatin1(data []byte) []byte {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return []byte(string(runes))
+}
+
+// hasControls returns whether more than a tenth of the sniffed bytes are control characters other
+// than whitespace, which is the case of binary data without NUL bytes.
+func hasControls(data []byte) bool {
+	if len(data) > sniffLength {
+		data = data[:sniffLength]
+	}
+	controls := 0
+	for _, b := range data {
+		if (b < 0x20 && b != '\t' && b != '\n' && b != '\r' && b != '\f') || b == 0x7F {
+			controls++
+		}
+	}
+	return controls*10 > len(data)
+}
+
+// isMinified returns whether a file is minified, by its name like "app.min.js" or by the average
+// length of its lines.
+func isMinified(path string, text []byte) bool {
+	if strings.Contains(path, ".min.") {
+		return true
+	}
+	if len(text) <= minifiedSize {
+		return false
+	}
+	lines := bytes.Count(text, []byte("\n")) + 1
+	return len(text)/lines > minifiedLine
+}
+
+// Editable returns an error if fixes cannot be applied to the data of the file, which is the case if
+// the data is omitted from the index, or transcoded from another encoding than UTF-8.
+func (file *File) Editable() error {
+	if file.Omitted {
+		return fmt.Errorf("content of the file is not stored")
+	}
+	if file.Encoding != "" && file.Encoding != EncodingUTF8BOM {
+		return fmt.Errorf("fixes of %s files are not supported", file.Encoding)
+	}
+	return nil
+}
+
+// Source returns the text of an editable file as it is stored in the repository, and the edits of
+// its typos shifted to the byte offsets of the text. The byte order mark stripped from UTF-8 files is
+// written back, which shifts the positions of typos counted in Data by its length.
+func (file *File) Source(edits []*Edit) (string, []*Edit) {
+	if file.Encoding != EncodingUTF8BOM {
+		return file.Data, edits
+	}
+
+	shifted := make([]*Edit, len(edits))
+	for i, edit := range edits {
+		e := *edit
+		e.Position += len(bomUTF8)
+		shifted[i] = &e
+	}
+	return string(bomUTF8) + file.Data, shifted
+}
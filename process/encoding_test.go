@@ -0,0 +1,85 @@
+package process
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		data     string
+		text     string
+		encoding string
+		skip     string
+	}{
+		{name: "UTF-8", path: "a.txt", data: "héllo\n", text: "héllo\n"},
+		{name: "UTF-8 BOM", path: "a.txt", data: "\xEF\xBB\xBFhéllo\n", text: "héllo\n", encoding: EncodingUTF8BOM},
+		{name: "UTF-16LE BOM", path: "a.txt", data: "\xFF\xFEh\x00\xE9\x00\n\x00", text: "hé\n", encoding: EncodingUTF16LE},
+		{name: "UTF-16BE BOM", path: "a.txt", data: "\xFE\xFF\x00h\x00\xE9\x00\n", text: "hé\n", encoding: EncodingUTF16BE},
+		{name: "UTF-16LE", path: "a.txt", data: "h\x00i\x00\n\x00", text: "hi\n", encoding: EncodingUTF16LE},
+		{name: "UTF-16BE", path: "a.txt", data: "\x00h\x00i\x00\n", text: "hi\n", encoding: EncodingUTF16BE},
+		{name: "UTF-32 BOM", path: "a.txt", data: "\xFF\xFE\x00\x00h\x00\x00\x00", skip: SkipEncoding},
+		{name: "Latin-1", path: "a.txt", data: "caf\xE9\n", text: "café\n", encoding: EncodingLatin1},
+		{name: "LFS pointer", path: "a.bin", data: lfsPointerPrefix + "oid sha256:abc\nsize 12\n", skip: SkipLFS},
+		{name: "NUL bytes", path: "a.bin", data: "\x89PNG\r\n\x1A\n\x00\x00\x00\rIHDR", skip: SkipBinary},
+		{name: "control bytes", path: "a.bin", data: "\x01\x02\x03\xFF\x04\x05", skip: SkipBinary},
+		{name: "minified name", path: "app.min.js", data: "var a=1;\n", skip: SkipMinified},
+	}
+
+	for _, test := range tests {
+		text, encoding, skip := decodeText(test.path, []byte(test.data))
+		if string(text) != test.text || encoding != test.encoding || skip != test.skip {
+			t.Errorf("%s: decodeText = %q, %q, %q, want %q, %q, %q",
+				test.name, text, encoding, skip, test.text, test.encoding, test.skip)
+		}
+	}
+}
+
+func TestSniffUTF16(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{data: "h\x00i\x00", want: EncodingUTF16LE},
+		{data: "\x00h\x00i", want: EncodingUTF16BE},
+		// Text of CJK characters has too few NUL bytes to be detected.
+		{data: "\x2D\x4E\x87\x65\n\x00\x2D\x4E\x87\x65\n\x00", want: ""},
+		{data: "hi", want: ""},
+		{data: "h\x00i", want: ""},
+		{data: "\x00\x00h\x00", want: ""},
+	}
+
+	for _, test := range tests {
+		if got := sniffUTF16([]byte(test.data)); got != test.want {
+			t.Errorf("sniffUTF16(%q) = %q, want %q", test.data, got, test.want)
+		}
+	}
+}
+
+func TestDecodeLatin1(t *testing.T) {
+	if got := string(decodeLatin1([]byte("\xA9 caf\xE9 \xFF"))); got != "© café ÿ" {
+		t.Errorf("decodeLatin1 = %q, want %q", got, "© café ÿ")
+	}
+}
+
+func TestIsMinified(t *testing.T) {
+	long := strings.Repeat("a", 5000)
+	tests := []struct {
+		path string
+		text string
+		want bool
+	}{
+		{path: "jquery.min.js", text: "", want: true},
+		{path: "app.js", text: long, want: true},
+		{path: "app.js", text: strings.Repeat("a", 999) + "\n" + strings.Repeat("a", 999) + "\n", want: false},
+		{path: "app.js", text: strings.Repeat(strings.Repeat("a", 80)+"\n", 100), want: false},
+	}
+
+	for _, test := range tests {
+		if got := isMinified(test.path, []byte(test.text)); got != test.want {
+			t.Errorf("isMinified(%q, %d bytes) = %v, want %v", test.path, len(test.text), got, test.want)
+		}
+	}
+}
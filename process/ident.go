@@ -0,0 +1,222 @@
+package process
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Minimum length of a word in an identifier to be checked. Shorter words are usually abbreviations.
+const minIdentWord = 4
+
+// Dictionary checks the spelling of single words.
+type Dictionary interface {
+	// Contains returns whether a word is spelled correctly.
+	Contains(word string) bool
+	// Suggest returns at most n suggestions for a misspelled word.
+	Suggest(word string, n int) []string
+}
+
+// Identifier is a declared identifier with misspelled words in its name.
+type Identifier struct {
+	SHA    string `json:"sha"`
+	FileID string `json:"fileId"`
+	Path   string `json:"path"`
+	Line   int    `json:"line"`
+	Name   string `json:"name"`
+	// Kind of the declaration, like "func", "method", "type", "field", "var" or "const".
+	Kind string `json:"kind"`
+	// Whether the identifier is exported.
+	Exported bool `json:"exported"`
+	// Whether renaming the identifier breaks the API of the package.
+	Breaking     bool          `json:"breaking"`
+	Misspellings []Misspelling `json:"misspellings"`
+	Valid        bool          `json:"valid"`
+}
+
+// Misspelling is a misspelled word in an identifier.
+type Misspelling struct {
+	Word        string   `json:"word"`
+	Suggestions []string `json:"suggestions"`
+}
+
+// declaration is an identifier declared in a Go file.
+type declaration struct {
+	name *ast.Ident
+	kind string
+	// Whether the identifier is part of the API, if it is exported.
+	api bool
+	// Name of the type the identifier belongs to, like the receiver of a method, or empty.
+	recv string
+}
+
+// FindIdentifiers parses a Go file and returns the declared identifiers with misspelled words.
+func FindIdentifiers(file *File, dict Dictionary) ([]*Identifier, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file.Path, file.Data, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// Identifiers in main packages, internal packages and tests are not part of any API.
+	api := f.Name.Name != "main" && !strings.HasSuffix(file.Path, "_test.go")
+	for _, dir := range strings.Split(path.Dir(file.Path), "/") {
+		if dir == "internal" {
+			api = false
+		}
+	}
+
+	idents := []*Identifier{}
+	for _, decl := range declarations(f, api) {
+		name := decl.name.Name
+		misspellings := []Misspelling{}
+		for _, word := range SplitIdentifier(name) {
+			if utf8.RuneCountInString(word) < minIdentWord || strings.ToUpper(word) == word {
+				continue
+			}
+			if dict.Contains(word) || dict.Contains(strings.ToLower(word)) {
+				continue
+			}
+			misspellings = append(misspellings, Misspelling{
+				Word:        word,
+				Suggestions: dict.Suggest(word, 5),
+			})
+		}
+		if len(misspellings) == 0 {
+			continue
+		}
+
+		exported := ast.IsExported(name)
+		idents = append(idents, &Identifier{
+			SHA:          identifierSHA(file.Path, decl.kind, decl.recv, name),
+			FileID:       file.SHA,
+			Path:         file.Path,
+			Line:         fset.Position(decl.name.Pos()).Line,
+			Name:         name,
+			Kind:         decl.kind,
+			Exported:     exported,
+			Breaking:     exported && decl.api,
+			Misspellings: misspellings,
+			Valid:        true,
+		})
+	}
+
+	return idents, nil
+}
+
+// declarations returns the identifiers declared in a file, including methods and fields.
+func declarations(f *ast.File, api bool) []declaration {
+	decls := []declaration{}
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil {
+				decls = append(decls, declaration{d.Name, "func", api, ""})
+				continue
+			}
+			// A method is part of the API if its receiver type is exported.
+			recv := ""
+			if len(d.Recv.List) > 0 {
+				recv = receiverName(d.Recv.List[0].Type)
+			}
+			decls = append(decls, declaration{d.Name, "method", api && ast.IsExported(recv), recv})
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					decls = append(decls, declaration{spec.Name, "type", api, ""})
+					decls = append(decls, members(spec.Name.Name, spec.Type, api && ast.IsExported(spec.Name.Name))...)
+				case *ast.ValueSpec:
+					kind := "var"
+					if d.Tok == token.CONST {
+						kind = "const"
+					}
+					for _, name := range spec.Names {
+						if name.Name != "_" {
+							decls = append(decls, declaration{name, kind, api, ""})
+						}
+					}
+				}
+			}
+		}
+	}
+	return decls
+}
+
+// members returns the fields of a struct type or the methods of an interface type, which belong to
+// the named type.
+func members(typeName string, expr ast.Expr, api bool) []declaration {
+	var list *ast.FieldList
+	kind := "field"
+	switch t := expr.(type) {
+	case *ast.StructType:
+		list = t.Fields
+	case *ast.InterfaceType:
+		list, kind = t.Methods, "method"
+	default:
+		return nil
+	}
+
+	decls := []declaration{}
+	for _, field := range list.List {
+		for _, name := range field.Names {
+			decls = append(decls, declaration{name, kind, api, typeName})
+		}
+	}
+	return decls
+}
+
+// receiverName returns the type name of a method receiver.
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		// Receivers of generic types with several type parameters, like "*Map[K, V]".
+		return receiverName(t.X)
+	}
+	return ""
+}
+
+// SplitIdentifier splits an identifier in camel case or snake case into words, like "parseHTTPHeader"
+// into "parse", "HTTP" and "Header". Digits are dropped.
+func SplitIdentifier(name string) []string {
+	words := []string{}
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool {
+		return r == '_' || unicode.IsDigit(r)
+	}) {
+		runes := []rune(part)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			// Split before an upper case letter following a lower case letter, like "parseHTTP", and
+			// before the last upper case letter of an acronym followed by a lower case letter, like
+			// "HTTPHeader".
+			lowerToUpper := unicode.IsLower(runes[i-1]) && unicode.IsUpper(runes[i])
+			acronymEnd := i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i+1])
+			if lowerToUpper || acronymEnd {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// identifierSHA returns the identifier of an Identifier, which is stable across scans. Methods and
+// fields of different types in a file are told apart by the type they belong to.
+func identifierSHA(path string, kind string, recv string, name string) string {
+	hash := sha1.New()
+	hash.Write([]byte(path + "\x00" + kind + "\x00" + recv + "\x00" + name))
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,72 @@
+package process
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/huangjiuyuan/typospider/language"
+)
+
+// LanguageRule selects the language of the files under a path.
+type LanguageRule struct {
+	// Path of a directory or a file, like "docs/zh".
+	Path string
+	// Language code of the files, or language.AutoLanguage.
+	Language string
+}
+
+// ParseLanguageRules parses comma separated rules in the form of "path=language".
+func ParseLanguageRules(text string) ([]LanguageRule, error) {
+	rules := []LanguageRule{}
+	if text == "" {
+		return rules, nil
+	}
+
+	for _, field := range strings.Split(text, ",") {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return nil, fmt.Errorf("invalid language rule %s", field)
+		}
+		rules = append(rules, LanguageRule{
+			Path:     strings.Trim(kv[0], "/"),
+			Language: kv[1],
+		})
+	}
+
+	return rules, nil
+}
+
+// languageOf returns the language of a file, selected by the most specific rule matching its path.
+func (proc *Processer) languageOf(path string) string {
+	path = strings.TrimPrefix(path, "/")
+	lang, longest := proc.Options.Language, -1
+	for _, rule := range proc.LanguageRules {
+		if path != rule.Path && !strings.HasPrefix(path, rule.Path+"/") {
+			continue
+		}
+		if len(rule.Path) > longest {
+			lang, longest = rule.Language, len(rule.Path)
+		}
+	}
+	return lang
+}
+
+// CheckLanguages makes sure that the Checker supports the languages used by the Processer.
+func (proc *Processer) CheckLanguages() error {
+	lr, err := proc.Checker.Languages()
+	if err != nil {
+		return err
+	}
+
+	langs := []string{proc.Options.Language}
+	for _, rule := range proc.LanguageRules {
+		langs = append(langs, rule.Language)
+	}
+	for _, lang := range langs {
+		if lang != language.AutoLanguage && !lr.Supports(lang) {
+			return fmt.Errorf("language %s is not supported by the checker", lang)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package process
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Functions whose string arguments are shown to users, keyed by import path and function name.
+var messageSinks = map[string]map[string]bool{
+	"fmt": {
+		"Errorf": true, "Print": true, "Printf": true, "Println": true,
+		"Fprint": true, "Fprintf": true, "Fprintln": true,
+	},
+	"log": {
+		"Print": true, "Printf": true, "Println": true,
+		"Fatal": true, "Fatalf": true, "Fatalln": true,
+		"Panic": true, "Panicf": true, "Panicln": true,
+	},
+	"errors": {
+		"New": true,
+	},
+	"github.com/pkg/errors": {
+		"New": true, "Errorf": true, "Wrap": true, "Wrapf": true,
+	},
+}
+
+// Fields of a cobra command which contain help text.
+var helpFields = map[string]bool{
+	"Short": true,
+	"Long":  true,
+}
+
+const cobraPath = "github.com/spf13/cobra"
+
+// ExtractStrings parses a Go file and returns the string literals shown to users, which are passed
+// to functions printing messages or creating errors, or used as help text of cobra commands.
+func (tokenizer *Tokenizer) ExtractStrings(path string, text string) ([]*Token, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, text, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve the package names of the imports.
+	imports := make(map[string]string)
+	for _, spec := range f.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := importPath[strings.LastIndex(importPath, "/")+1:]
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = importPath
+	}
+
+	// selector returns the import path and the name of a qualified identifier like "fmt.Errorf".
+	selector := func(expr ast.Expr) (string, string) {
+		sel, ok := expr.(*ast.SelectorExpr)
+		if !ok {
+			return "", ""
+		}
+		pkg, ok := sel.X.(*ast.Ident)
+		if !ok {
+			return "", ""
+		}
+		return imports[pkg.Name], sel.Sel.Name
+	}
+
+	tokens := []*Token{}
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			importPath, name := selector(n.Fun)
+			if !messageSinks[importPath][name] {
+				return true
+			}
+			for _, arg := range n.Args {
+				tokens = append(tokens, literalTokens(fset, arg)...)
+			}
+		case *ast.CompositeLit:
+			importPath, name := selector(n.Type)
+			if importPath != cobraPath || name != "Command" {
+				return true
+			}
+			for _, elt := range n.Elts {
+				kv, ok := elt.(*ast.KeyValueExpr)
+				if !ok {
+					continue
+				}
+				if key, ok := kv.Key.(*ast.Ident); ok && helpFields[key.Name] {
+					tokens = append(tokens, literalTokens(fset, kv.Value)...)
+				}
+			}
+		}
+		return true
+	})
+
+	return tokens, nil
+}
+
+// literalTokens returns a token for each string literal in an expression, including the operands of
+// string concatenations.
+func literalTokens(fset *token.FileSet, expr ast.Expr) []*Token {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return nil
+		}
+		tok := literalToken(e.Value, fset.Position(e.Pos()))
+		if tok == nil {
+			return nil
+		}
+		return []*Token{tok}
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return nil
+		}
+		return append(literalTokens(fset, e.X), literalTokens(fset, e.Y)...)
+	case *ast.ParenExpr:
+		return literalTokens(fset, e.X)
+	}
+	return nil
+}
+
+// literalToken returns a token of the value of a string literal at the position. Characters written
+// as escape sequences are not mapped back to the source.
+func literalToken(lit string, pos token.Position) *Token {
+	tok := &Token{
+		Line: pos.Line,
+		Kind: KindString,
+	}
+	body := lit[1 : len(lit)-1]
+	start := pos.Offset + 1
+
+	// A raw string is copied verbatim from the source, except carriage returns which are discarded.
+	if lit[0] == '`' {
+		for _, part := range strings.SplitAfter(body, "\r") {
+			tok.Add(strings.TrimSuffix(part, "\r"), start)
+			start += len(part)
+		}
+	} else {
+		run := 0
+		for i := 0; i < len(body); {
+			if body[i] != '\\' {
+				i++
+				continue
+			}
+			tok.Add(body[run:i], start+run)
+			value, _, tail, err := strconv.UnquoteChar(body[i:], '"')
+			if err != nil {
+				return nil
+			}
+			next := len(body) - len(tail)
+			tok.Add(escapedText(value), -1)
+			i, run = next, next
+		}
+		tok.Add(body[run:], start+run)
+	}
+
+	if strings.TrimSpace(tok.Text) == "" {
+		return nil
+	}
+	return tok
+}
+
+// escapedText returns the text of an escaped character.
+func escapedText(r rune) string {
+	buf := make([]byte, utf8.UTFMax)
+	return string(buf[:utf8.EncodeRune(buf, r)])
+}
@@ -0,0 +1,295 @@
+package process
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Extensions of documentation files, in which all text is prose rather than code.
+var documentExts = map[string]bool{
+	".md":       true,
+	".markdown": true,
+	".rst":      true,
+}
+
+// isDocument returns whether a file is a Markdown or reStructuredText document.
+func isDocument(path string) bool {
+	return documentExts[extension(path)]
+}
+
+// Patterns of the blocks of Markdown documents.
+var (
+	mdFenceExp     = regexp.MustCompile("^(`{3,}|~{3,})")
+	mdHeadingExp   = regexp.MustCompile(`^(#{1,6})(\s+|$)`)
+	mdSetextExp    = regexp.MustCompile(`^(=+|-+)\s*$`)
+	mdBreakExp     = regexp.MustCompile(`^([-*_])(\s*[-*_]){2,}\s*$`)
+	mdListExp      = regexp.MustCompile(`^([-*+]|\d{1,9}[.)])\s+(\[[ xX]\]\s+)?`)
+	mdReferenceExp = regexp.MustCompile(`^\[[^\]]+\]:\s`)
+)
+
+// sourceLine is a line of a document.
+type sourceLine struct {
+	// Text of the line without the line break.
+	text string
+	// Byte offset of the line in the source.
+	pos int
+	// Line number, starting from 1.
+	num int
+}
+
+// sourceLines splits the text into lines.
+func sourceLines(text string) []sourceLine {
+	lines := []sourceLine{}
+	pos := 0
+	for i, l := range strings.SplitAfter(text, "\n") {
+		if l == "" {
+			continue
+		}
+		lines = append(lines, sourceLine{
+			text: strings.TrimRight(l, "\r\n"),
+			pos:  pos,
+			num:  i + 1,
+		})
+		pos += len(l)
+	}
+	return lines
+}
+
+// indentOf returns the indentation of a line in columns, where a tab counts as four columns, and the
+// byte offset of the first non-blank character.
+func indentOf(text string) (int, int) {
+	indent := 0
+	for i, r := range text {
+		switch r {
+		case ' ':
+			indent++
+		case '\t':
+			indent += 4 - indent%4
+		default:
+			return indent, i
+		}
+	}
+	return indent, len(text)
+}
+
+// MarkdownExtractor extracts the headings, paragraphs and list items of a Markdown document. Front
+// matter, code blocks, HTML blocks, tables and link targets are skipped.
+type MarkdownExtractor struct{}
+
+// NewMarkdownExtractor returns a MarkdownExtractor with an error if necessary.
+func NewMarkdownExtractor() (*MarkdownExtractor, error) {
+	return &MarkdownExtractor{}, nil
+}
+
+// Extract extracts the prose of a Markdown document. Lines of a paragraph or a list item are joined
+// by spaces.
+func (ext *MarkdownExtractor) Extract(path string, text string) ([]*Token, error) {
+	lines := sourceLines(text)
+	tokens := []*Token{}
+
+	// Skip the front matter of static site generators.
+	start := 0
+	if len(lines) > 0 && (lines[0].text == "---" || lines[0].text == "+++") {
+		for i := 1; i < len(lines); i++ {
+			if lines[i].text == lines[0].text {
+				start = i + 1
+				break
+			}
+		}
+	}
+
+	var token *Token
+	var fence string
+	var html, list bool
+	for _, l := range lines[start:] {
+		indent, off := indentOf(l.text)
+		content := l.text[off:]
+
+		// Skip fenced code blocks.
+		if fence != "" {
+			if strings.HasPrefix(content, fence) && strings.TrimSpace(strings.Trim(content, fence[:1])) == "" {
+				fence = ""
+			}
+			continue
+		}
+		if content == "" {
+			token, html = nil, false
+			continue
+		}
+		if html {
+			continue
+		}
+		if m := mdFenceExp.FindString(content); m != "" {
+			token, fence = nil, m
+			continue
+		}
+		// Skip indented code blocks, unless the line continues a list item.
+		if indent >= 4 && token == nil && !list {
+			continue
+		}
+		if indent == 0 && !mdListExp.MatchString(content) {
+			list = false
+		}
+
+		// Strip the markers of block quotes.
+		for strings.HasPrefix(content, ">") {
+			n := 1
+			if strings.HasPrefix(content, "> ") {
+				n = 2
+			}
+			content, off = content[n:], off+n
+		}
+		content = strings.TrimRight(content, " \t")
+		if content == "" {
+			token = nil
+			continue
+		}
+
+		switch {
+		case token == nil && strings.HasPrefix(content, "<"):
+			// Skip HTML blocks until the next blank line.
+			html = true
+		case mdReferenceExp.MatchString(content), strings.HasPrefix(content, "|"):
+			// Skip link reference definitions and tables.
+			token = nil
+		case token != nil && token.Kind == KindParagraph && mdSetextExp.MatchString(content):
+			// An underline turns the paragraph into a heading.
+			token.Kind = KindHeading
+			token = nil
+		case mdBreakExp.MatchString(content):
+			token = nil
+		case mdHeadingExp.MatchString(content):
+			m := mdHeadingExp.FindString(content)
+			heading := content[len(m):]
+			// Strip the optional closing sequence, like in "## Title ##".
+			if t := strings.TrimRight(heading, "#"); t != heading && (t == "" || strings.HasSuffix(t, " ")) {
+				heading = strings.TrimRight(t, " \t")
+			}
+			token = &Token{Line: l.num, Kind: KindHeading}
+			markdownInline(token, heading, l.pos+off+len(m))
+			tokens = append(tokens, token)
+			token = nil
+		case mdListExp.MatchString(content):
+			m := mdListExp.FindString(content)
+			token = &Token{Line: l.num, Kind: KindListItem}
+			markdownInline(token, content[len(m):], l.pos+off+len(m))
+			tokens = append(tokens, token)
+			list = true
+		default:
+			if token == nil {
+				token = &Token{Line: l.num, Kind: KindParagraph}
+				tokens = append(tokens, token)
+			} else {
+				token.Add(" ", -1)
+			}
+			markdownInline(token, content, l.pos+off)
+		}
+	}
+
+	// Drop the tokens without any text, like headings consisting of links only.
+	result := []*Token{}
+	for _, token := range tokens {
+		if strings.TrimSpace(token.Text) != "" {
+			result = append(result, token)
+		}
+	}
+	return result, nil
+}
+
+// markdownInline adds the inline text of a Markdown line at the given byte offset of the source to
+// the token. Emphasis markers, link targets and inline HTML are skipped, and code spans and autolinks
+// are replaced by a placeholder, so that they are never checked as prose.
+func markdownInline(token *Token, text string, pos int) {
+	plain := 0
+	flush := func(end int) {
+		if end > plain {
+			token.Add(text[plain:end], pos+plain)
+		}
+	}
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text) && strings.IndexByte("\\`*_{}[]()<>#+-.!|~", text[i+1]) >= 0:
+			// Escaped punctuation is kept without the backslash.
+			flush(i)
+			plain = i + 1
+			i += 2
+		case c == '`':
+			n := len(text[i:]) - len(strings.TrimLeft(text[i:], "`"))
+			end := strings.Index(text[i+n:], text[i:i+n])
+			if end < 0 {
+				i += n
+				continue
+			}
+			flush(i)
+			token.Add(codePlaceholder, -1)
+			i += n + end + n
+			plain = i
+		case c == '[' || (c == '!' && i+1 < len(text) && text[i+1] == '['):
+			open := i
+			if c == '!' {
+				open++
+			}
+			close := strings.IndexByte(text[open:], ']')
+			if close < 0 || open+close+1 >= len(text) || (text[open+close+1] != '(' && text[open+close+1] != '[') {
+				i = open + 1
+				continue
+			}
+			close += open
+			closer := ")"
+			if text[close+1] == '[' {
+				closer = "]"
+			}
+			end := strings.Index(text[close+1:], closer)
+			if end < 0 {
+				i = open + 1
+				continue
+			}
+			// Keep the text of the link and skip its target.
+			flush(i)
+			markdownInline(token, text[open+1:close], pos+open+1)
+			i = close + 1 + end + 1
+			plain = i
+		case c == '<':
+			end := strings.IndexByte(text[i:], '>')
+			if end < 0 || end == 1 || strings.ContainsAny(text[i+1:i+end], " \t") && !isTagStart(text[i+1]) {
+				i++
+				continue
+			}
+			// Replace autolinks by the placeholder, and skip inline HTML.
+			flush(i)
+			if inner := text[i+1 : i+end]; strings.Contains(inner, "://") {
+				token.Add(codePlaceholder, -1)
+			}
+			i += end + 1
+			plain = i
+		case c == '*' || (c == '~' && i+1 < len(text) && text[i+1] == '~'):
+			flush(i)
+			i++
+			for i < len(text) && text[i] == c {
+				i++
+			}
+			plain = i
+		case c == '_' && (i == 0 || !isWordByte(text[i-1]) || i+1 == len(text) || !isWordByte(text[i+1])):
+			// Underscores inside words, like in snake case, are not emphasis.
+			flush(i)
+			i++
+			plain = i
+		default:
+			i++
+		}
+	}
+	flush(len(text))
+}
+
+// isTagStart returns whether a byte may follow "<" at the start of an HTML tag.
+func isTagStart(c byte) bool {
+	return c == '/' || c == '!' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isWordByte returns whether a byte is a letter, a digit or an underscore, or a part of a multibyte
+// character.
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 0x80 || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
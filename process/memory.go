@@ -0,0 +1,118 @@
+package process
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/huangjiuyuan/typospider/github"
+)
+
+// SkipLarge is the reason of skipping blobs larger than the maximum size.
+const SkipLarge = "too large"
+
+// DefaultMaxBlobSize is the default maximum size of blobs read into memory. Larger blobs are skipped,
+// which are rarely hand-written source files or documents.
+const DefaultMaxBlobSize = 1 << 20
+
+// DefaultMaxQueuedBytes is the default maximum number of bytes of the blobs waiting in the blob queue
+// with their data, like the blobs of a tarball or fetched in bulk.
+const DefaultMaxQueuedBytes = 64 << 20
+
+// byteBudget bounds the number of bytes held at the same time. A request larger than the limit is
+// granted when no byte is held, so that it does not block forever.
+type byteBudget struct {
+	// Lock of the bytes held.
+	mu sync.Mutex
+	// Signal of released bytes.
+	cond *sync.Cond
+	// Number of bytes held.
+	used int64
+}
+
+// newByteBudget returns an empty byteBudget.
+func newByteBudget() *byteBudget {
+	b := &byteBudget{}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// acquire blocks until n bytes can be held within the limit, and holds them. There is no limit if
+// the limit is not positive.
+func (b *byteBudget) acquire(n int64, limit int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for limit > 0 && b.used > 0 && b.used+n > limit {
+		b.cond.Wait()
+	}
+	b.used += n
+}
+
+// release releases n bytes held.
+func (b *byteBudget) release(n int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.used -= n
+	b.cond.Broadcast()
+}
+
+// enqueueData enqueues a blob whose data is filled to the blob queue. It blocks while the blobs in the
+// queue hold more than MaxQueuedBytes, so that fast sources, like tarballs, do not outpace the checks.
+func (proc *Processer) enqueueData(blob *github.Blob) {
+	proc.queued.acquire(int64(len(*blob.Data)), proc.MaxQueuedBytes)
+	proc.blobqueue.Enqueue(blob)
+}
+
+// dequeued releases the bytes held by a blob taken from the blob queue.
+func (proc *Processer) dequeued(blob *github.Blob) {
+	if blob.Data != nil {
+		proc.queued.release(int64(len(*blob.Data)))
+	}
+}
+
+// tooLarge returns whether a blob of the size is larger than MaxBlobSize.
+func (proc *Processer) tooLarge(size int) bool {
+	return proc.MaxBlobSize > 0 && size > proc.MaxBlobSize
+}
+
+// fetchBlob gets raw content of a blob, which is streamed if the provider supports it, so that no
+// more than MaxBlobSize bytes are read. The reason is returned instead if the blob is too large. The
+// extractors parse whole files, like Go files, so the content is still read into memory.
+func (proc *Processer) fetchBlob(blob *github.Blob) ([]byte, string, error) {
+	if proc.tooLarge(blob.Size) {
+		return nil, SkipLarge, nil
+	}
+
+	stream, ok := proc.Provider.(StreamProvider)
+	if !ok {
+		data, err := proc.Provider.GetBlob(blob.URL)
+		if err != nil {
+			return nil, "", err
+		}
+		if proc.tooLarge(len(data)) {
+			return nil, SkipLarge, nil
+		}
+		return data, "", nil
+	}
+
+	body, err := stream.OpenBlob(blob.URL)
+	if err != nil {
+		return nil, "", err
+	}
+	defer body.Close()
+
+	var r io.Reader = body
+	if proc.MaxBlobSize > 0 {
+		// Read a byte more than the maximum to tell whether the blob is larger.
+		r = io.LimitReader(body, int64(proc.MaxBlobSize)+1)
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, "", fmt.Errorf("error on reading a blob response: %s", err)
+	}
+	if proc.tooLarge(len(data)) {
+		return nil, SkipLarge, nil
+	}
+	return data, "", nil
+}
@@ -0,0 +1,194 @@
+package process
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/huangjiuyuan/typospider/github"
+)
+
+const (
+	// MessageCommit is the kind of commit messages.
+	MessageCommit = "commit"
+	// MessagePull is the kind of pull request titles and descriptions.
+	MessagePull = "pull"
+)
+
+// Trailers of commit messages, like "Signed-off-by: name <email>", which are not prose.
+var trailerExp = regexp.MustCompile(`^[A-Z][\w-]*-[Bb]y: `)
+
+// Message is a commit message, or the title and the description of a pull request.
+type Message struct {
+	// Identifier of the message, which is the commit SHA or the pull request number.
+	ID string
+	// Kind of the message, like MessageCommit or MessagePull.
+	Kind string
+	// Title of the message, which is the first line of a commit message.
+	Title string
+	// Body of the message, which may be written in Markdown.
+	Body string
+	// URL of the commit or the pull request page.
+	URL string
+}
+
+// File returns a file containing the text of the message, which is identified by the message ID.
+func (msg *Message) File() *File {
+	text := msg.Title
+	if msg.Body != "" {
+		text += "\n\n" + msg.Body
+	}
+	file, _ := NewFile(msg.Kind+"/"+msg.ID, len(text), msg.ID, msg.URL, []byte(text))
+	return file
+}
+
+// LocalCommits reads at most limit commit messages of the current branch of a local git repository,
+// the most recent first.
+func LocalCommits(dir string, limit int) ([]*Message, error) {
+	out, err := exec.Command("git", "-C", dir, "log", "-n", strconv.Itoa(limit), "--format=%H%x1f%B%x1e").Output()
+	if err != nil {
+		return nil, fmt.Errorf("error on reading commits of %s: %s", dir, err)
+	}
+
+	messages := []*Message{}
+	for _, record := range strings.Split(string(out), "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x1f", 2)
+		if len(fields) < 2 {
+			continue
+		}
+		title, body := splitMessage(fields[1])
+		messages = append(messages, &Message{
+			ID:    fields[0],
+			Kind:  MessageCommit,
+			Title: title,
+			Body:  body,
+		})
+	}
+
+	return messages, nil
+}
+
+// GitHubCommits lists at most limit commit messages of a GitHub repository, the most recent first.
+func GitHubCommits(vis *github.Visitor, owner string, repo string, limit int) ([]*Message, error) {
+	messages := []*Message{}
+	for page := 1; len(messages) < limit; page++ {
+		commits, err := vis.ListCommits(owner, repo, page)
+		if err != nil {
+			return messages, fmt.Errorf("error on listing commits: %s", err)
+		}
+		for _, c := range commits {
+			if len(messages) == limit {
+				break
+			}
+			title, body := splitMessage(c.Commit.Message)
+			messages = append(messages, &Message{
+				ID:    c.SHA,
+				Kind:  MessageCommit,
+				Title: title,
+				Body:  body,
+				URL:   c.HTMLURL,
+			})
+		}
+		if len(commits) < github.PageSize {
+			break
+		}
+	}
+
+	return messages, nil
+}
+
+// GitHubPullRequests lists at most limit pull requests of a GitHub repository, the most recent first.
+func GitHubPullRequests(vis *github.Visitor, owner string, repo string, limit int) ([]*Message, error) {
+	messages := []*Message{}
+	for page := 1; len(messages) < limit; page++ {
+		prs, err := vis.ListPullRequests(owner, repo, page)
+		if err != nil {
+			return messages, fmt.Errorf("error on listing pull requests: %s", err)
+		}
+		for _, pr := range prs {
+			if len(messages) == limit {
+				break
+			}
+			messages = append(messages, &Message{
+				ID:    strconv.Itoa(pr.Number),
+				Kind:  MessagePull,
+				Title: pr.Title,
+				Body:  strings.Replace(pr.Body, "\r\n", "\n", -1),
+				URL:   pr.HTMLURL,
+			})
+		}
+		if len(prs) < github.PageSize {
+			break
+		}
+	}
+
+	return messages, nil
+}
+
+// splitMessage splits a commit message into the subject line and the body.
+func splitMessage(text string) (string, string) {
+	text = strings.TrimSpace(text)
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		return text[:i], strings.TrimSpace(text[i+1:])
+	}
+	return text, ""
+}
+
+// ProcessMessages checks the messages through the same pipeline as files. Each message with typos is
+// indexed to the message index by its ID, and the typos are indexed to the typo index.
+func (proc *Processer) ProcessMessages(messages []*Message, index string, typoIndex string) error {
+	// Load the triaged typos before the typo index is recreated.
+	triaged, err := proc.Elastic.TriagedTypos(typoIndex)
+	if err != nil {
+		fmt.Printf("[Warning] Load triaged typos failed: %s\n", err)
+	} else {
+		proc.triaged = triaged
+	}
+
+	err = proc.Elastic.CreateFileIndex(index)
+	if err != nil {
+		return err
+	}
+	err = proc.Elastic.CreateTypoIndex(typoIndex)
+	if err != nil {
+		return err
+	}
+
+	ext, err := NewMarkdownExtractor()
+	if err != nil {
+		return err
+	}
+	for _, msg := range messages {
+		file := msg.File()
+		tokens, err := ext.Extract(file.Path, file.Data)
+		if err != nil {
+			fmt.Printf("[Error] Extract message %s failed: %s\n", file.Path, err)
+			continue
+		}
+
+		// Skip the trailers of commit messages.
+		prose := []*Token{}
+		for _, token := range tokens {
+			if !trailerExp.MatchString(token.Text) {
+				prose = append(prose, token)
+			}
+		}
+
+		err = proc.checkTokens(file, prose, typoIndex)
+		if err != nil {
+			fmt.Printf("[Error] Check message %s failed: %s\n", file.Path, err)
+			continue
+		}
+
+		if len(file.Fragments) > 0 {
+			_, err = proc.Elastic.IndexFile(index, *file)
+			if err != nil {
+				fmt.Printf("[Error] Index message %s failed: %s\n", file.Path, err)
+			}
+		}
+	}
+
+	return nil
+}
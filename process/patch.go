@@ -0,0 +1,268 @@
+package process
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/scanner"
+)
+
+// Number of unchanged lines around each change in a unified diff.
+const diffContext = 3
+
+// Edit replaces a range of bytes in a file.
+type Edit struct {
+	// Byte offset of the range in the file.
+	Position int
+	// Text expected in the range.
+	Old string
+	// Text replacing the range.
+	New string
+	// Typo fixed by the edit.
+	Typo *Typo
+}
+
+// NewEdit returns an Edit fixing the typo with its replacement of the given index.
+func NewEdit(typo *Typo, choice int) (*Edit, error) {
+	if typo.Position < 0 {
+		return nil, fmt.Errorf("typo %s cannot be located in the source", typo.SHA)
+	}
+	if choice < 0 || choice >= len(typo.Match.Replacements) {
+		return nil, fmt.Errorf("typo %s has no replacement %d", typo.SHA, choice)
+	}
+	replacement := typo.Match.Replacements[choice]
+	if replacement.Value == nil {
+		return nil, fmt.Errorf("typo %s has an empty replacement %d", typo.SHA, choice)
+	}
+
+	// Recover the erroneous text from the context of the match if the typo does not record it.
+	old := typo.Text
+	if old == "" {
+		ctx := typo.Match.Context
+		offset := byteOffset(ctx.Text, ctx.Offset)
+		length := byteOffset(ctx.Text[offset:], ctx.Length)
+		old = ctx.Text[offset : offset+length]
+	}
+
+	return &Edit{
+		Position: typo.Position,
+		Old:      old,
+		New:      *replacement.Value,
+		Typo:     typo,
+	}, nil
+}
+
+// LoadEdits loads the valid typos of a file from the index, and returns the edits fixing them with
+// their replacements of the given index. Typos which cannot be fixed are skipped.
+func (es *Elastic) LoadEdits(index string, file *File, choice int) ([]*Edit, error) {
+	edits := []*Edit{}
+	seen := make(map[string]bool)
+	for _, frag := range file.Fragments {
+		for _, id := range frag.Typos {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			typo, err := es.LoadTypo(index, id)
+			if err != nil {
+				return nil, err
+			}
+			// Skip typos which are triaged as false positives or belong to another file.
+			if !typo.Valid || typo.FileID != file.SHA {
+				continue
+			}
+
+			edit, err := NewEdit(typo, choice)
+			if err != nil {
+				fmt.Printf("[Warning] Skip typo %s: %s\n", id, err)
+				continue
+			}
+			edits = append(edits, edit)
+		}
+	}
+
+	return edits, nil
+}
+
+// Patch applies the edits to a file and returns a unified diff of the change, which can be applied
+// with "git apply".
+func Patch(path string, data string, edits []*Edit) (string, error) {
+	patched, err := Apply(path, data, edits)
+	if err != nil {
+		return "", err
+	}
+
+	return diff(path, data, patched), nil
+}
+
+// Apply applies the edits to a file and returns the patched text. Each edit of a source file must
+// replace text inside a comment, and the code of the file must stay unchanged after all edits are
+// applied. Documents may be edited anywhere.
+func Apply(path string, data string, edits []*Edit) (string, error) {
+	sorted := make([]*Edit, len(edits))
+	copy(sorted, edits)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Position < sorted[j].Position
+	})
+
+	code := !isDocument(path)
+	comments := commentRanges(data)
+	var buf bytes.Buffer
+	last := 0
+	for _, edit := range sorted {
+		end := edit.Position + len(edit.Old)
+		if edit.Position < last {
+			return "", fmt.Errorf("edit at %d overlaps with a previous edit", edit.Position)
+		}
+		if end > len(data) || data[edit.Position:end] != edit.Old {
+			return "", fmt.Errorf("edit at %d does not match the text %q", edit.Position, edit.Old)
+		}
+		if strings.ContainsAny(edit.New, "\r\n") || strings.Contains(edit.New, "*/") {
+			return "", fmt.Errorf("edit at %d inserts an invalid text %q", edit.Position, edit.New)
+		}
+		if code && !inRanges(comments, edit.Position, end) {
+			return "", fmt.Errorf("edit at %d is not inside a comment", edit.Position)
+		}
+
+		buf.WriteString(data[last:edit.Position])
+		buf.WriteString(edit.New)
+		last = end
+	}
+	buf.WriteString(data[last:])
+	patched := buf.String()
+
+	// Make sure that only comment bytes are changed.
+	if code && !equalCode(data, patched) {
+		return "", fmt.Errorf("edits change the code of %s", path)
+	}
+
+	return patched, nil
+}
+
+// commentRanges returns the byte ranges of comments in the text.
+func commentRanges(text string) [][2]int {
+	var s scanner.Scanner
+	s.Init(strings.NewReader(text))
+	s.Mode = scanner.GoTokens &^ scanner.SkipComments
+	s.Error = func(*scanner.Scanner, string) {}
+
+	ranges := [][2]int{}
+	for tok := s.Scan(); tok != scanner.EOF; tok = s.Scan() {
+		if tok == scanner.Comment {
+			ranges = append(ranges, [2]int{s.Position.Offset, s.Position.Offset + len(s.TokenText())})
+		}
+	}
+	return ranges
+}
+
+// inRanges returns whether [start, end) falls into one of the ranges.
+func inRanges(ranges [][2]int, start int, end int) bool {
+	for _, r := range ranges {
+		if start >= r[0] && end <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// equalCode returns whether two texts contain the same tokens besides comments.
+func equalCode(a string, b string) bool {
+	scan := func(text string) []string {
+		var s scanner.Scanner
+		s.Init(strings.NewReader(text))
+		s.Mode = scanner.GoTokens
+		s.Error = func(*scanner.Scanner, string) {}
+
+		tokens := []string{}
+		for tok := s.Scan(); tok != scanner.EOF; tok = s.Scan() {
+			tokens = append(tokens, s.TokenText())
+		}
+		return tokens
+	}
+
+	ta, tb := scan(a), scan(b)
+	if len(ta) != len(tb) {
+		return false
+	}
+	for i := range ta {
+		if ta[i] != tb[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// diff returns a unified diff between two versions of a file with the same number of lines.
+func diff(path string, old string, new string) string {
+	ol, nl := splitLines(old), splitLines(new)
+	changed := []int{}
+	for i := range ol {
+		if ol[i] != nl[i] {
+			changed = append(changed, i)
+		}
+	}
+	if len(changed) == 0 {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "diff --git a/%s b/%s\n--- a/%s\n+++ b/%s\n", path, path, path, path)
+	for i := 0; i < len(changed); {
+		// Merge changes whose contexts overlap into one hunk.
+		j := i
+		for j+1 < len(changed) && changed[j+1]-changed[j] <= 2*diffContext {
+			j++
+		}
+		start := changed[i] - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := changed[j] + diffContext + 1
+		if end > len(ol) {
+			end = len(ol)
+		}
+
+		fmt.Fprintf(&buf, "@@ -%d,%d +%d,%d @@\n", start+1, end-start, start+1, end-start)
+		for k := start; k < end; {
+			if ol[k] == nl[k] {
+				writeLine(&buf, " ", ol[k])
+				k++
+				continue
+			}
+			run := k
+			for run < end && ol[run] != nl[run] {
+				run++
+			}
+			for l := k; l < run; l++ {
+				writeLine(&buf, "-", ol[l])
+			}
+			for l := k; l < run; l++ {
+				writeLine(&buf, "+", nl[l])
+			}
+			k = run
+		}
+		i = j + 1
+	}
+
+	return buf.String()
+}
+
+// splitLines splits the text into lines, each of which keeps its line break.
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// writeLine writes a line of a hunk with the prefix.
+func writeLine(buf *bytes.Buffer, prefix string, line string) {
+	buf.WriteString(prefix)
+	buf.WriteString(line)
+	if !strings.HasSuffix(line, "\n") {
+		buf.WriteString("\n\\ No newline at end of file\n")
+	}
+}
@@ -0,0 +1,149 @@
+package process
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	source := "package main\n\n// Pakage main is teh entry.\nfunc main() {}\n"
+	tests := []struct {
+		name  string
+		path  string
+		data  string
+		edits []*Edit
+		// Patched text, or empty if the edits are rejected.
+		want string
+	}{
+		{
+			name: "comment",
+			path: "main.go",
+			data: source,
+			edits: []*Edit{
+				{Position: 32, Old: "teh", New: "the"},
+				{Position: 17, Old: "Pakage", New: "Package"},
+			},
+			want: "package main\n\n// Package main is the entry.\nfunc main() {}\n",
+		},
+		{
+			name: "overlapping",
+			path: "main.go",
+			data: source,
+			edits: []*Edit{
+				{Position: 17, Old: "Pakage", New: "Package"},
+				{Position: 20, Old: "age main", New: "age mine"},
+			},
+		},
+		{
+			name:  "mismatched",
+			path:  "main.go",
+			data:  source,
+			edits: []*Edit{{Position: 17, Old: "Package", New: "Packages"}},
+		},
+		{
+			name:  "outside comment",
+			path:  "main.go",
+			data:  source,
+			edits: []*Edit{{Position: 8, Old: "main", New: "mian"}},
+		},
+		{
+			name:  "line break",
+			path:  "main.go",
+			data:  source,
+			edits: []*Edit{{Position: 32, Old: "teh", New: "the\n"}},
+		},
+		{
+			// The edit is inside the comment, but turns the rest of the line into code.
+			name:  "code changed",
+			path:  "main.go",
+			data:  source,
+			edits: []*Edit{{Position: 14, Old: "//", New: "x/"}},
+		},
+		{
+			name:  "document",
+			path:  "README.md",
+			data:  "# Teh project\n",
+			edits: []*Edit{{Position: 2, Old: "Teh", New: "The"}},
+			want:  "# The project\n",
+		},
+		{
+			name:  "CRLF",
+			path:  "main.go",
+			data:  "package main\r\n\r\n// teh entry\r\nfunc main() {}\r\n",
+			edits: []*Edit{{Position: 19, Old: "teh", New: "the"}},
+			want:  "package main\r\n\r\n// the entry\r\nfunc main() {}\r\n",
+		},
+	}
+
+	for _, test := range tests {
+		got, err := Apply(test.path, test.data, test.edits)
+		if test.want == "" {
+			if err == nil {
+				t.Errorf("%s: Apply = %q, want an error", test.name, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Apply failed: %s", test.name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: Apply = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestApplyBOM(t *testing.T) {
+	// Data of the file is stored without the byte order mark, and edits are located in Data.
+	file := &File{Path: "main.go", Data: "// teh entry\npackage main\n", Encoding: EncodingUTF8BOM}
+	edits := []*Edit{{Position: 3, Old: "teh", New: "the"}}
+
+	data, shifted := file.Source(edits)
+	got, err := Apply(file.Path, data, shifted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "\xEF\xBB\xBF// the entry\npackage main\n"; got != want {
+		t.Errorf("Apply = %q, want %q", got, want)
+	}
+	if edits[0].Position != 3 {
+		t.Errorf("edit is moved to %d, want it unchanged", edits[0].Position)
+	}
+}
+
+func TestPatch(t *testing.T) {
+	lines := []string{}
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, "// line")
+	}
+	lines[1] = "// teh first"
+	lines[4] = "// teh second"
+	lines[16] = "// teh third"
+	data := strings.Join(lines, "\n")
+
+	edits := []*Edit{}
+	for _, line := range []int{1, 4, 16} {
+		position := len(strings.Join(lines[:line], "\n")) + len("\n// ")
+		edits = append(edits, &Edit{Position: position, Old: "teh", New: "the"})
+	}
+
+	got, err := Patch("a.go", data, edits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Changes whose contexts overlap share a hunk, and the last line has no line break.
+	want := "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n" +
+		"@@ -1,8 +1,8 @@\n" +
+		" // line\n-// teh first\n+// the first\n // line\n // line\n" +
+		"-// teh second\n+// the second\n // line\n // line\n // line\n" +
+		"@@ -14,7 +14,7 @@\n" +
+		" // line\n // line\n // line\n-// teh third\n+// the third\n // line\n // line\n" +
+		" // line\n\\ No newline at end of file\n"
+	if got != want {
+		t.Errorf("Patch = %q, want %q", got, want)
+	}
+
+	if got, err := Patch("a.go", data, nil); err != nil || got != "" {
+		t.Errorf("Patch without edits = %q, %v, want an empty diff", got, err)
+	}
+}
@@ -12,33 +12,83 @@ import (
 	"github.com/huangjiuyuan/typospider/util/ratelimiter"
 )
 
-// Processer contains a Visitor to visit GitHub API, a LanguageTool to check the texts, a Elastic
+// DefaultFileIndex is the default name of the index of the files with typos, which is the project
+// scanned first.
+const DefaultFileIndex = "kubernetes"
+
+// Processer contains a Visitor to visit GitHub API, a Checker to check the texts, a Elastic
 // agent to operate on a Elasticsearch server, and a Tokenizer to tokenize text of a file. It produces
 // trees by visiting GitHub API, and produces blobs by consuming trees it produces.
 type Processer struct {
 	// Visitor to visit GitHub API.
 	Visitor *github.Visitor
-	// LanguageTool to check the texts.
-	LanguageTool *language.LanguageTool
+	// Provider of the trees and blobs, which is the Visitor by default.
+	Provider Provider
+	// Checker to check the texts, like a LanguageTool.
+	Checker language.Checker
 	// Elastic agent to operate on a Elasticsearch server.
 	Elastic *Elastic
 	// Tokenizer to tokenize text of a file.
 	Tokenizer *Tokenizer
+	// Extractors of files keyed by extensions in lower case, like ".go". Tokenizer is used for other
+	// files.
+	Extractors map[string]Extractor
 	// Rate of the GitHub visitor.
 	Rate time.Duration
+	// Maximum number of characters checked in one LanguageTool request.
+	BatchSize int
+	// Whether GitHub repositories are downloaded as tarballs instead of walking their trees and blobs.
+	Archives bool
+	// Whether submodules hosted by GitHub are followed into their repositories at the pinned commits.
+	Submodules bool
+	// Maximum size of blobs read into memory, or 0 for no limit. Larger blobs are skipped.
+	MaxBlobSize int
+	// Maximum number of bytes of the blobs waiting in the blob queue with their data, or 0 for no
+	// limit. Sources filling the data of blobs block while the limit is reached.
+	MaxQueuedBytes int64
+	// Whether the content of files with typos is stored in Elasticsearch, which is needed by fixes.
+	StoreContent bool
+	// Name of the Elasticsearch index of the files with typos, which is recreated by each scan.
+	FileIndex string
+	// Number of blobs fetched per request if the provider gets blobs in bulk, or 0 for fetching blobs
+	// one by one.
+	BlobBatchSize int
+	// Options of LanguageTool requests, where the text is filled for each request.
+	Options language.CheckOptions
+	// Whether code spans, identifiers and URLs in comments are sent as markup to be skipped.
+	Annotate bool
+	// Rules selecting the languages of files by their paths, overriding the language of Options.
+	LanguageRules []LanguageRule
+	// Whether doc comments are checked to start with the declared names.
+	DocRules bool
+	// Whether string literals shown to users, like error messages and help text, are checked.
+	Strings bool
+	// Dictionary for checking declared identifiers. Misspelled identifiers are reported only if it is
+	// set.
+	Dictionary Dictionary
 
 	// Wait for goroutines to finish.
 	wg sync.WaitGroup
 	// Keep concurrency under control
 	sema chan struct{}
-	// Thread safe rate limiting queue for processing trees.
-	treequeue ratelimiter.Interface
 	// Thread safe rate limiting queue for processing blobs.
 	blobqueue ratelimiter.Interface
+	// Typos triaged as accepted or ignored, keyed by their fingerprints.
+	triaged map[string]*Typo
+	// Lock of the summaries.
+	mu sync.Mutex
+	// Results aggregated per repository, keyed by the full names of the repositories.
+	summaries map[string]*RepositorySummary
+	// Files which are not checked.
+	skipped []SkippedFile
+	// Commits of submodules followed, keyed by "owner/name@sha".
+	followed map[string]bool
+	// Bytes held by the blobs in the blob queue.
+	queued *byteBudget
 }
 
 // NewProcesser returns a Processer with an error if necessary.
-func NewProcesser(rate int, vis *github.Visitor, lt *language.LanguageTool, concurrency int, initialize bool) (*Processer, error) {
+func NewProcesser(rate int, vis *github.Visitor, checker language.Checker, concurrency int, initialize bool) (*Processer, error) {
 	if rate < 1000 {
 		fmt.Printf("[Warning] API rate exceeded threshold\n")
 	}
@@ -55,17 +105,54 @@ func NewProcesser(rate int, vis *github.Visitor, lt *language.LanguageTool, conc
 		return nil, err
 	}
 
+	// Create the extractors of Go files and documents.
+	goExt, err := NewGoExtractor()
+	if err != nil {
+		return nil, err
+	}
+	mdExt, err := NewMarkdownExtractor()
+	if err != nil {
+		return nil, err
+	}
+	rstExt, err := NewRSTExtractor()
+	if err != nil {
+		return nil, err
+	}
+
 	p := &Processer{
-		Visitor:      vis,
-		LanguageTool: lt,
-		Elastic:      es,
-		Tokenizer:    tk,
-		Rate:         time.Duration(rate) * time.Millisecond,
+		Visitor:   vis,
+		Provider:  vis,
+		Checker:   checker,
+		Elastic:   es,
+		Tokenizer: tk,
+		Extractors: map[string]Extractor{
+			".go":       goExt,
+			".md":       mdExt,
+			".markdown": mdExt,
+			".rst":      rstExt,
+		},
+		Rate:           time.Duration(rate) * time.Millisecond,
+		BatchSize:      DefaultBatchSize,
+		BlobBatchSize:  DefaultBlobBatchSize,
+		MaxBlobSize:    DefaultMaxBlobSize,
+		MaxQueuedBytes: DefaultMaxQueuedBytes,
+		StoreContent:   true,
+		FileIndex:      DefaultFileIndex,
+		Options:        language.CheckOptions{Language: "en"},
+		Annotate:       true,
 
 		wg:        sync.WaitGroup{},
 		sema:      make(chan struct{}, concurrency),
-		treequeue: ratelimiter.New(),
 		blobqueue: ratelimiter.New(),
+		triaged:   make(map[string]*Typo),
+		summaries: make(map[string]*RepositorySummary),
+		followed:  make(map[string]bool),
+		queued:    newByteBudget(),
+	}
+
+	// Space the requests of the visitor by the rate, unless it already shares a budget.
+	if vis != nil && vis.Budget == nil {
+		vis.Budget = github.NewBudget(p.Rate)
 	}
 
 	return p, nil
@@ -73,10 +160,13 @@ func NewProcesser(rate int, vis *github.Visitor, lt *language.LanguageTool, conc
 
 // ProcessTree wraps the tree processing function.
 func (proc *Processer) ProcessTree(url string) {
-	err := proc.processTree(url)
+	err := proc.processTree(url, "")
 	if err != nil {
 		fmt.Printf("[Error] Processing tree failed: %s\n", err)
 	}
+
+	// Send a signal that no more blob is produced.
+	proc.Finish()
 }
 
 // ProcessBlob wraps the blob processing function.
@@ -87,13 +177,16 @@ func (proc *Processer) ProcessBlob() {
 	}
 }
 
-func (proc *Processer) processTree(url string) error {
+// processTree enqueues the blobs of a tree in a repository, like "owner/name", to the blob queue.
+// Symbolic links and submodules are skipped, unless submodules are followed.
+func (proc *Processer) processTree(url string, repo string) error {
 	// Produce a tree then enqueue to the tree queue.
-	t, recursive, err := proc.Visitor.GetTree(url)
+	t, recursive, err := proc.Provider.GetTree(url)
 	if err != nil {
 		return err
 	}
 
+	walk := &treeWalk{}
 	if recursive {
 		for _, sm := range t.Tree {
 			// Skip "vendor" and "staging" folders.
@@ -101,80 +194,156 @@ func (proc *Processer) processTree(url string) error {
 			if dir[0] == "vendor" || dir[0] == "staging" {
 				continue
 			}
-
-			// Produce a blob then enqueue to the blob queue if the submodule is a valid blob.
-			if sm.Type == "blob" {
-				if filepath.Ext(sm.Path) == ".go" {
-					blob := &github.Blob{
-						Path: sm.Path,
-						Size: *sm.Size,
-						SHA:  sm.SHA,
-						URL:  sm.URL,
-						Data: nil,
-					}
-					proc.blobqueue.Enqueue(blob)
-				}
-			}
+			proc.addEntry(walk, repo, sm.Path, sm)
 		}
-		proc.treequeue.ShutDown()
-		return nil
+		proc.enqueueBlobs(walk.blobs)
+		return proc.processSubmodules(repo, walk)
 	}
 
-	proc.treequeue.Enqueue(t)
+	trees := ratelimiter.New()
+	trees.Enqueue(t)
 	for {
 		// Shut down if received a signal from dequeue operation.
-		item, shutdown := proc.treequeue.Dequeue()
+		item, shutdown := trees.Dequeue()
 		if shutdown {
 			break
 		}
 
 		if t, ok := item.(*github.Tree); ok {
+			walk.blobs = nil
 			for _, sm := range t.Tree {
 				// Skip "vendor" and "staging" folders.
 				if sm.Path == "vendor" || sm.Path == "staging" {
 					continue
 				}
 
-				if sm.Type == "blob" {
-					// Produce a blob then enqueue to the blob queue if the submodule is a valid blob.
-					if filepath.Ext(sm.Path) == ".go" {
-						blob := &github.Blob{
-							Path: setPath(t.Path, sm.Path),
-							Size: *sm.Size,
-							SHA:  sm.SHA,
-							URL:  sm.URL,
-							Data: nil,
-						}
-						proc.blobqueue.Enqueue(blob)
-					}
-				} else if sm.Type == "tree" {
+				if sm.Type == "tree" {
 					// Produce a tree then enqueue to the tree queue if the submodule is a tree.
-					tree, recursive, err := proc.Visitor.GetTree(sm.URL)
-					if recursive {
-						return fmt.Errorf("visiting tree recursively in non-recursive mode")
+					tree, recursive, err := proc.Provider.GetTree(sm.URL)
+					if err != nil {
+						fmt.Printf("[Error] Get tree %s failed: %s\n", sm.URL, err)
+						continue
 					}
 					tree.Path = setPath(t.Path, sm.Path)
-					if err != nil {
-						fmt.Printf("[Error] Get tree %s failed: %s\n", t.URL, err)
+					if recursive {
+						// Only a truncated tree is walked level by level, and its subtrees are listed
+						// at once if they are small enough.
+						for _, e := range tree.Tree {
+							proc.addEntry(walk, repo, setPath(tree.Path, e.Path), e)
+						}
+						continue
 					}
-					proc.treequeue.Enqueue(tree)
+					trees.Enqueue(tree)
+					continue
+				}
+				if t.Path == "" && sm.Path == ".gitmodules" {
+					walk.gitmodules = sm.URL
 				}
+				proc.addEntry(walk, repo, setPath(t.Path, sm.Path), sm)
 			}
+			proc.enqueueBlobs(walk.blobs)
 			// Send a signal if the tree queue is done and no tree is produced.
-			if proc.treequeue.Len() == 0 {
-				proc.treequeue.ShutDown()
+			if trees.Len() == 0 {
+				trees.ShutDown()
 			}
 		} else {
 			fmt.Printf("[Error] Parse tree %#v failed\n", item)
 		}
 	}
 
-	return nil
+	return proc.processSubmodules(repo, walk)
+}
+
+// addEntry classifies an entry of a tree other than a subtree by its type and mode. Blobs accepted
+// are added to the walk, symbolic links are skipped, and submodules are collected to be followed.
+func (proc *Processer) addEntry(walk *treeWalk, repo string, path string, sm *github.Submodule) {
+	switch {
+	case sm.Type == "commit" || sm.Mode == github.ModeSubmodule:
+		walk.submodules = append(walk.submodules, &github.Submodule{
+			Path: path,
+			Mode: sm.Mode,
+			Type: sm.Type,
+			SHA:  sm.SHA,
+			URL:  sm.URL,
+		})
+	case sm.Type != "blob":
+	case path == ".gitmodules":
+		walk.gitmodules = sm.URL
+	case !proc.Accepts(path):
+	case sm.Mode == github.ModeSymlink:
+		// The blob of a symbolic link contains the path of its target, which is not prose.
+		proc.skip(repo, path, SkipSymlink)
+	case sm.Size != nil && proc.tooLarge(*sm.Size):
+		proc.skip(repo, path, SkipLarge)
+	default:
+		size := 0
+		if sm.Size != nil {
+			size = *sm.Size
+		}
+		walk.blobs = append(walk.blobs, &github.Blob{
+			Path: path,
+			Size: size,
+			SHA:  sm.SHA,
+			Mode: sm.Mode,
+			URL:  sm.URL,
+			Repo: repo,
+			Data: nil,
+		})
+	}
+}
+
+// enqueueBlobs enqueues the blobs to the blob queue. If the provider gets blobs in bulk, their data is
+// fetched in batches first, and the blobs left out are fetched one by one by processBlob.
+func (proc *Processer) enqueueBlobs(blobs []*github.Blob) {
+	bulk, ok := proc.Provider.(BulkProvider)
+	if !ok || proc.BlobBatchSize <= 0 {
+		for _, blob := range blobs {
+			proc.blobqueue.Enqueue(blob)
+		}
+		return
+	}
+
+	for i := 0; i < len(blobs); i += proc.BlobBatchSize {
+		batch := blobs[i:]
+		if len(batch) > proc.BlobBatchSize {
+			batch = batch[:proc.BlobBatchSize]
+		}
+		urls := make([]string, len(batch))
+		for j, blob := range batch {
+			urls[j] = blob.URL
+		}
+
+		data, err := bulk.GetBlobs(urls)
+		if err != nil {
+			fmt.Printf("[Warning] Get blobs in bulk failed: %s\n", err)
+		}
+		for _, blob := range batch {
+			d, ok := data[blob.URL]
+			if !ok {
+				proc.blobqueue.Enqueue(blob)
+				continue
+			}
+			if proc.tooLarge(len(d)) {
+				proc.skip(blob.Repo, blob.Path, SkipLarge)
+				continue
+			}
+			blob.Data = &d
+			proc.enqueueData(blob)
+		}
+	}
 }
 
 func (proc *Processer) processBlob() error {
+	// Load the triaged typos before the typo index is recreated.
+	triaged, err := proc.Elastic.TriagedTypos("typo")
+	if err != nil {
+		fmt.Printf("[Warning] Load triaged typos failed: %s\n", err)
+	} else {
+		proc.triaged = triaged
+	}
+
 	// Create the project index.
-	err := proc.Elastic.CreateFileIndex("kubernetes")
+	err = proc.Elastic.CreateFileIndex(proc.FileIndex)
 	if err != nil {
 		fmt.Printf("[Error] Create index failed: %s\n", err)
 	}
@@ -185,6 +354,14 @@ func (proc *Processer) processBlob() error {
 		fmt.Printf("[Error] Create index failed: %s\n", err)
 	}
 
+	// Create the identifier index if misspelled identifiers are reported.
+	if proc.Dictionary != nil {
+		err = proc.Elastic.CreateIdentifierIndex("identifier")
+		if err != nil {
+			fmt.Printf("[Error] Create index failed: %s\n", err)
+		}
+	}
+
 	for {
 		// Shut down if received a signal from dequeue operation.
 		item, shutdown := proc.blobqueue.Dequeue()
@@ -193,22 +370,30 @@ func (proc *Processer) processBlob() error {
 		}
 
 		if b, ok := item.(*github.Blob); ok {
-			data, err := proc.Visitor.GetBlob(b.URL)
-			if err != nil {
-				fmt.Printf("[Error] Get blob %s failed: %s\n", b.URL, err)
+			proc.dequeued(b)
+			// Fetch the blob unless its data is filled by the source, like a crawler.
+			if b.Data == nil {
+				data, reason, err := proc.fetchBlob(b)
+				if err != nil {
+					fmt.Printf("[Error] Get blob %s failed: %s\n", b.URL, err)
+					continue
+				}
+				if reason != "" {
+					proc.skip(b.Repo, b.Path, reason)
+					continue
+				}
+				b.Data = &data
+			}
+			// Identify the blob by its content if the provider does not list SHAs.
+			if b.SHA == "" {
+				b.SHA = github.BlobSHA(*b.Data)
 			}
-			b.Data = &data
 
 			// Block until the semaphore has room. If the concurrency is under control, process the
 			// typo produced by the blob.
 			proc.wg.Add(1)
 			proc.sema <- struct{}{}
 			go proc.processTypo(b)
-
-			// Send a signal if the tree queue is done and no tree is produced.
-			if proc.treequeue.ShuttingDown() {
-				proc.blobqueue.ShutDown()
-			}
 		} else {
 			fmt.Printf("[Error] Parse blob %#v failed\n", item)
 		}
@@ -220,65 +405,220 @@ func (proc *Processer) processBlob() error {
 }
 
 func (proc *Processer) processTypo(b *github.Blob) {
-	// Create a file from the blob.
-	file, err := NewFile(b.Path, b.Size, b.SHA, b.URL, *b.Data)
+	defer func() {
+		proc.wg.Done()
+		<-proc.sema
+	}()
+
+	// Skip the blobs whose content is not text, and transcode the text to UTF-8.
+	text, encoding, reason := decodeText(b.Path, *b.Data)
+	if reason != "" {
+		proc.skip(b.Repo, b.Path, reason)
+		return
+	}
+
+	// Create a file from the blob, which copies the text, so the data of the blob is released.
+	file, err := NewFile(b.Path, b.Size, b.SHA, b.URL, text)
 	if err != nil {
 		fmt.Printf("[Error] Create file %s failed: %s\n", b.Path, err)
 		return
 	}
+	file.Repo = b.Repo
+	file.Encoding = encoding
+	file.Mode = b.Mode
+	b.Data, text = nil, nil
 
-	// Tokenize the file text.
-	tokens, err := proc.Tokenizer.Tokenize(file.Data)
+	// Extract the tokens from the file text.
+	tokens, err := proc.extract(file)
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
 
-	// Check error for each token.
-	for offset, token := range tokens {
-		cr, err := proc.LanguageTool.Check(token, "en", "", "", "", "", "", "", false)
+	// Extract the user-facing string literals of a Go file.
+	if proc.Strings && extension(file.Path) == ".go" {
+		strs, err := proc.Tokenizer.ExtractStrings(file.Path, file.Data)
 		if err != nil {
-			fmt.Println(err)
-			return
+			fmt.Printf("[Error] Parse file %s failed: %s\n", file.Path, err)
 		}
+		tokens = append(tokens, strs...)
+	}
 
-		if len(cr.Matches) > 0 {
-			frag := Fragment{offset, []string{}}
-			for _, match := range cr.Matches {
-				// Filter out any invalid typo.
-				valid := filterTypo(match)
-				if valid {
-					// Add a typo to the file fragment if it is valid.
-					typo, err := frag.AddTypo(file.SHA, *match)
-					if err != nil {
-						fmt.Printf("[Error] Add typo %s failed: %s\n", match.Context.Text, err)
-						continue
-					}
+	err = proc.checkTokens(file, tokens, "typo")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 
-					// Index the typo to Elasticsearch.
-					_, err = proc.Elastic.IndexTypo("typo", *typo)
-					if err != nil {
-						fmt.Printf("[Error] Index typo %s failed: %s\n", typo.Match.Context.Text, err)
-						continue
-					}
-				}
-			}
-			if len(frag.Typos) > 0 {
-				file.Fragments = append(file.Fragments, frag)
-			}
-		}
+	// Check the declared identifiers of a Go file.
+	if proc.Dictionary != nil && extension(file.Path) == ".go" {
+		proc.processIdentifiers(file)
 	}
 
+	proc.summarize(file)
+
 	// If the file contains any fragment, index the file to Elasticsearch.
 	if len(file.Fragments) > 0 {
-		_, err = proc.Elastic.IndexFile("kubernetes", *file)
+		if !proc.StoreContent {
+			file.Data, file.Omitted = "", true
+		}
+		_, err = proc.Elastic.IndexFile(proc.FileIndex, *file)
 		if err != nil {
 			fmt.Printf("[Error] Index file %s failed: %s\n", file.SHA, err)
 		}
 	}
+}
+
+// Accepts returns whether a file is processed by its path, which is the case for files with an
+// extractor, like Go files and documents, outside "vendor" and "staging" folders.
+func (proc *Processer) Accepts(path string) bool {
+	dir := strings.Split(path, "/")
+	if dir[0] == "vendor" || dir[0] == "staging" {
+		return false
+	}
+	_, ok := proc.Extractors[extension(path)]
+	return ok
+}
+
+// extension returns the extension of a path in lower case, like ".md" for "README.MD", which selects
+// the extractor of the file.
+func extension(path string) string {
+	return strings.ToLower(filepath.Ext(path))
+}
+
+// Enqueue enqueues a blob from another source than GitHub trees, like a crawler. The blob is fetched
+// with the Visitor if its data is nil.
+func (proc *Processer) Enqueue(blob *github.Blob) {
+	if blob.Data == nil {
+		proc.blobqueue.Enqueue(blob)
+		return
+	}
+	if proc.tooLarge(len(*blob.Data)) {
+		proc.skip(blob.Repo, blob.Path, SkipLarge)
+		return
+	}
+	proc.enqueueData(blob)
+}
+
+// Finish sends a signal that no more blob is enqueued, so that ProcessBlob returns after the blobs
+// enqueued are processed.
+func (proc *Processer) Finish() {
+	proc.blobqueue.ShutDown()
+}
+
+// extract extracts the tokens of a file with the extractor of its extension. Go files which cannot
+// be parsed are tokenized by the Tokenizer.
+func (proc *Processer) extract(file *File) ([]*Token, error) {
+	if ext, ok := proc.Extractors[extension(file.Path)]; ok {
+		tokens, err := ext.Extract(file.Path, file.Data)
+		if err == nil {
+			return tokens, nil
+		}
+		fmt.Printf("[Warning] Extract file %s failed: %s\n", file.Path, err)
+	}
+
+	return proc.Tokenizer.Extract(file.Path, file.Data)
+}
+
+// checkTokens checks the tokens of a file, and indexes the typos found to the typo index. The tokens
+// are checked in batches to reduce round trips to LanguageTool. When detecting languages
+// automatically, each token is checked alone, since a file may contain comments in several languages.
+func (proc *Processer) checkTokens(file *File, tokens []*Token, index string) error {
+	lang := proc.languageOf(file.Path)
+	size := proc.BatchSize
+	if lang == language.AutoLanguage {
+		size = 0
+	}
+	for _, batch := range batchTokens(tokens, size) {
+		opts := proc.Options
+		opts.Language = lang
+		if proc.Annotate {
+			opts.Data = batch.annotated()
+		} else {
+			opts.Text = batch.text
+		}
+		cr, err := proc.Checker.CheckWithOptions(&opts)
+		if err != nil {
+			return err
+		}
+
+		for i, matches := range batch.split(cr.Matches) {
+			token := batch.tokens[i]
+			if proc.DocRules {
+				if match := checkDoc(token); match != nil {
+					matches = append(matches, match)
+				}
+			}
+			proc.addTypos(index, file, token, matches, cr.Detected())
+		}
+	}
+
+	return nil
+}
+
+// addTypos adds the valid typos of a token in the language to a fragment of the file, and indexes the
+// typos to the typo index.
+func (proc *Processer) addTypos(index string, file *File, token *Token, matches []*language.Match, lang string) {
+	frag := Fragment{token.Line, []string{}}
+	for _, match := range matches {
+		// Filter out any invalid typo.
+		valid := filterTypo(match)
+		if !valid {
+			continue
+		}
+
+		// Keep the triage decision of an ignored typo without reporting it again.
+		triaged, ok := proc.triaged[Fingerprint(*match)]
+		if ok && triaged.Status == TypoIgnored {
+			_, err := proc.Elastic.IndexTypo(index, *triaged)
+			if err != nil {
+				fmt.Printf("[Error] Index typo %s failed: %s\n", triaged.Match.Context.Text, err)
+			}
+			continue
+		}
+
+		// Add a typo to the file fragment if it is valid.
+		typo, err := frag.AddTypo(file.SHA, *match)
+		if err != nil {
+			fmt.Printf("[Error] Add typo %s failed: %s\n", match.Context.Text, err)
+			continue
+		}
+		typo.Locate(file, token)
+		typo.Language = lang
+		// Keep the triage decision of an accepted typo, which is still reported to be fixed.
+		if ok {
+			typo.Valid = triaged.Valid
+			typo.Status = triaged.Status
+			typo.Reason = triaged.Reason
+			typo.Author = triaged.Author
+		}
+
+		// Index the typo to Elasticsearch.
+		_, err = proc.Elastic.IndexTypo(index, *typo)
+		if err != nil {
+			fmt.Printf("[Error] Index typo %s failed: %s\n", typo.Match.Context.Text, err)
+			continue
+		}
+	}
+	if len(frag.Typos) > 0 {
+		file.Fragments = append(file.Fragments, frag)
+	}
+}
 
-	proc.wg.Done()
-	<-proc.sema
+// processIdentifiers indexes the declared identifiers with misspelled words in a Go file.
+func (proc *Processer) processIdentifiers(file *File) {
+	idents, err := FindIdentifiers(file, proc.Dictionary)
+	if err != nil {
+		fmt.Printf("[Error] Parse file %s failed: %s\n", file.Path, err)
+		return
+	}
+
+	for _, ident := range idents {
+		_, err = proc.Elastic.IndexIdentifier("identifier", *ident)
+		if err != nil {
+			fmt.Printf("[Error] Index identifier %s failed: %s\n", ident.Name, err)
+		}
+	}
 }
 
 func filterTypo(match *language.Match) bool {
@@ -0,0 +1,35 @@
+package process
+
+import (
+	"io"
+
+	"github.com/huangjiuyuan/typospider/github"
+)
+
+// Provider provides the trees and blobs of repositories hosted by a git service, like GitHub, GitLab,
+// Gitea or Bitbucket Server, in the same model as GitHub.
+type Provider interface {
+	// GetTreeURL returns the URL of the tree of a ref in a repository.
+	GetTreeURL(owner string, repo string, ref string) string
+	// GetTree gets a tree, and returns whether it contains all entries under the tree recursively.
+	GetTree(url string) (*github.Tree, bool, error)
+	// GetBlob gets raw content of a blob.
+	GetBlob(url string) ([]byte, error)
+}
+
+// BulkProvider is a Provider which gets many blobs per request.
+type BulkProvider interface {
+	Provider
+	// GetBlobs gets raw content of blobs keyed by their URLs. Blobs which cannot be fetched in bulk,
+	// like binary or large blobs, are left out.
+	GetBlobs(urls []string) (map[string][]byte, error)
+}
+
+// StreamProvider is a Provider which streams the content of blobs, so that large blobs are not read
+// in full.
+type StreamProvider interface {
+	Provider
+	// OpenBlob requests raw content of a blob, and returns the body to be streamed. The caller must
+	// close it.
+	OpenBlob(url string) (io.ReadCloser, error)
+}
@@ -0,0 +1,252 @@
+package process
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/huangjiuyuan/typospider/github"
+	"github.com/olivere/elastic"
+)
+
+// Batch contains the fixes of the files under a directory, which are sent in one pull request.
+type Batch struct {
+	// Full name of the repository of the files, like "owner/name".
+	Repo string
+	// Directory of the files.
+	Dir string
+	// Files to fix.
+	Files []*File
+	// Fixed text of each file, keyed by the file SHA.
+	Fixed map[string]string
+	// Edits applied to the files.
+	Edits []*Edit
+}
+
+// LoadBatches loads the accepted typos of a repository, like "owner/name", from the typo index, fixes
+// them with their top replacements, and batches the fixes per directory. Files which cannot be fixed
+// are skipped.
+func (es *Elastic) LoadBatches(fileIndex string, typoIndex string, repo string) ([]*Batch, error) {
+	query := elastic.NewBoolQuery().Must(
+		elastic.NewTermQuery("status", TypoAccepted),
+		elastic.NewTermQuery("repo", repo),
+	)
+	typos, err := es.SearchTypos(typoIndex, query)
+	if err != nil {
+		return nil, err
+	}
+
+	// Group the typos by the files they belong to.
+	ids := []string{}
+	grouped := make(map[string][]*Typo)
+	for _, typo := range typos {
+		if _, ok := grouped[typo.FileID]; !ok {
+			ids = append(ids, typo.FileID)
+		}
+		grouped[typo.FileID] = append(grouped[typo.FileID], typo)
+	}
+
+	batches := make(map[string]*Batch)
+	for _, id := range ids {
+		file, err := es.LoadFile(fileIndex, id)
+		if err != nil {
+			fmt.Printf("[Warning] Skip file %s: %s\n", id, err)
+			continue
+		}
+		file.Path = strings.TrimPrefix(file.Path, "/")
+		if err := file.Editable(); err != nil {
+			fmt.Printf("[Warning] Skip file %s: %s\n", file.Path, err)
+			continue
+		}
+
+		edits := []*Edit{}
+		for _, typo := range grouped[id] {
+			edit, err := NewEdit(typo, 0)
+			if err != nil {
+				fmt.Printf("[Warning] Skip typo %s: %s\n", typo.SHA, err)
+				continue
+			}
+			edits = append(edits, edit)
+		}
+		if len(edits) == 0 {
+			continue
+		}
+
+		data, shifted := file.Source(edits)
+		fixed, err := Apply(file.Path, data, shifted)
+		if err != nil {
+			fmt.Printf("[Warning] Skip file %s: %s\n", file.Path, err)
+			continue
+		}
+
+		// Directories of different repositories are never batched together.
+		dir := path.Dir(file.Path)
+		key := file.Repo + ":" + dir
+		batch, ok := batches[key]
+		if !ok {
+			batch = &Batch{
+				Repo:  file.Repo,
+				Dir:   dir,
+				Fixed: make(map[string]string),
+			}
+			batches[key] = batch
+		}
+		batch.Files = append(batch.Files, file)
+		batch.Fixed[file.SHA] = fixed
+		batch.Edits = append(batch.Edits, edits...)
+	}
+
+	result := []*Batch{}
+	for _, batch := range batches {
+		result = append(result, batch)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Repo != result[j].Repo {
+			return result[i].Repo < result[j].Repo
+		}
+		return result[i].Dir < result[j].Dir
+	})
+
+	return result, nil
+}
+
+// Branch returns the name of the branch holding the fixes of the batch.
+func (batch *Batch) Branch() string {
+	if batch.Dir == "." {
+		return "typospider/root"
+	}
+	return "typospider/" + strings.Replace(batch.Dir, "/", "-", -1)
+}
+
+// Title returns the title of the pull request of the batch.
+func (batch *Batch) Title() string {
+	if batch.Dir == "." {
+		return "Fix typos in comments"
+	}
+	return fmt.Sprintf("Fix typos in comments under %s", batch.Dir)
+}
+
+// Description returns the description of the pull request of the batch, which lists each change.
+func (batch *Batch) Description() string {
+	var buf bytes.Buffer
+	buf.WriteString("This pull request fixes the following typos in comments:\n\n")
+	for _, edit := range batch.Edits {
+		fmt.Fprintf(&buf, "- `%s` line %d: `%s` → `%s`", edit.Typo.Path, edit.Typo.Line, edit.Old, edit.New)
+		if edit.Typo.Match.Message != "" {
+			fmt.Fprintf(&buf, " (%s)", edit.Typo.Match.Message)
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("\nThe typos are found by [Typospider](https://github.com/huangjiuyuan/typospider) and reviewed by the maintainers.\n")
+	return buf.String()
+}
+
+// only returns a copy of the batch which contains the given files only.
+func (batch *Batch) only(files []*File) *Batch {
+	b := &Batch{
+		Repo:  batch.Repo,
+		Dir:   batch.Dir,
+		Files: files,
+		Fixed: batch.Fixed,
+	}
+	for _, edit := range batch.Edits {
+		for _, file := range files {
+			if edit.Typo.FileID == file.SHA {
+				b.Edits = append(b.Edits, edit)
+				break
+			}
+		}
+	}
+	return b
+}
+
+// OpenPullRequests commits the fixes of each batch to a branch of the fork of the repository, and
+// opens a pull request against the default branch of the repository. Files which have been changed
+// since they were scanned are left out.
+func OpenPullRequests(vis *github.Visitor, owner string, repo string, batches []*Batch) ([]*github.PullRequest, error) {
+	upstream, err := vis.GetRepository(owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	fork, err := vis.Fork(owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	ref, err := vis.GetReference(owner, repo, "heads/"+upstream.DefaultBranch)
+	if err != nil {
+		return nil, err
+	}
+	base, err := vis.GetCommit(owner, repo, ref.Object.SHA)
+	if err != nil {
+		return nil, err
+	}
+
+	prs := []*github.PullRequest{}
+	for _, batch := range batches {
+		if batch.Repo != "" && batch.Repo != owner+"/"+repo {
+			fmt.Printf("[Warning] Skip fixes under %s: they belong to %s\n", batch.Dir, batch.Repo)
+			continue
+		}
+
+		kept := []*File{}
+		entries := []*github.TreeEntry{}
+		for _, file := range batch.Files {
+			content, err := vis.GetContent(owner, repo, file.Path, base.SHA)
+			if err != nil {
+				fmt.Printf("[Warning] Skip file %s: %s\n", file.Path, err)
+				continue
+			}
+			if content.SHA != file.SHA {
+				fmt.Printf("[Warning] Skip file %s: changed since it was scanned\n", file.Path)
+				continue
+			}
+
+			// Keep the mode of the file, like an executable script. Files indexed without a mode are
+			// regular files.
+			mode := file.Mode
+			if mode == "" {
+				mode = github.ModeFile
+			}
+			kept = append(kept, file)
+			entries = append(entries, &github.TreeEntry{
+				Path:    file.Path,
+				Mode:    mode,
+				Type:    "blob",
+				Content: batch.Fixed[file.SHA],
+			})
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		batch = batch.only(kept)
+
+		tree, err := vis.CreateTree(fork.Owner.Login, fork.Name, base.Tree.SHA, entries)
+		if err != nil {
+			return prs, err
+		}
+		commit, err := vis.CreateCommit(fork.Owner.Login, fork.Name, batch.Title(), tree.SHA, []string{base.SHA})
+		if err != nil {
+			return prs, err
+		}
+		_, err = vis.CreateReference(fork.Owner.Login, fork.Name, "refs/heads/"+batch.Branch(), commit.SHA)
+		if err != nil {
+			return prs, err
+		}
+
+		pr, err := vis.CreatePullRequest(owner, repo, &github.PullRequest{
+			Title: batch.Title(),
+			Body:  batch.Description(),
+			Head:  fork.Owner.Login + ":" + batch.Branch(),
+			Base:  upstream.DefaultBranch,
+		})
+		if err != nil {
+			return prs, err
+		}
+		prs = append(prs, pr)
+	}
+
+	return prs, nil
+}
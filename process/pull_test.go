@@ -0,0 +1,136 @@
+package process
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/huangjiuyuan/typospider/github"
+)
+
+func TestOpenPullRequests(t *testing.T) {
+	var tree struct {
+		BaseTree string              `json:"base_tree"`
+		Tree     []*github.TreeEntry `json:"tree"`
+	}
+	var commit struct {
+		Tree    string   `json:"tree"`
+		Parents []string `json:"parents"`
+	}
+	var update struct {
+		SHA   string `json:"sha"`
+		Force bool   `json:"force"`
+	}
+	var pull map[string]string
+
+	decode := func(r *http.Request, v interface{}) {
+		if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+			t.Errorf("%s %s: %s", r.Method, r.URL, err)
+		}
+	}
+	upstream := `{"name": "proj", "full_name": "up/proj", "owner": {"login": "up"}, "default_branch": "main"}`
+	fork := `{"name": "proj", "full_name": "bot/proj", "owner": {"login": "bot"}, "default_branch": "main"}`
+	routes := map[string]func(w http.ResponseWriter, r *http.Request){
+		"GET /repos/up/proj":                     func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, upstream) },
+		"POST /repos/up/proj/forks":              func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, fork) },
+		"GET /repos/bot/proj/git/ref/heads/main": func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, `{"object": {"sha": "base"}}`) },
+		"GET /repos/up/proj/git/ref/heads/main":  func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, `{"object": {"sha": "base"}}`) },
+		"GET /repos/up/proj/git/commits/base": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"sha": "base", "tree": {"sha": "basetree"}}`)
+		},
+		"GET /repos/up/proj/contents/hack/run%20all.sh": func(w http.ResponseWriter, r *http.Request) {
+			if ref := r.URL.Query().Get("ref"); ref != "base" {
+				t.Errorf("content requested at %q, want base", ref)
+			}
+			fmt.Fprint(w, `{"type": "file", "sha": "sha1"}`)
+		},
+		"GET /repos/up/proj/contents/hack/main.go": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"type": "file", "sha": "changed"}`)
+		},
+		"POST /repos/bot/proj/git/trees": func(w http.ResponseWriter, r *http.Request) {
+			decode(r, &tree)
+			fmt.Fprint(w, `{"sha": "newtree"}`)
+		},
+		"POST /repos/bot/proj/git/commits": func(w http.ResponseWriter, r *http.Request) {
+			decode(r, &commit)
+			fmt.Fprint(w, `{"sha": "newcommit"}`)
+		},
+		// The branch is left by a previous run.
+		"GET /repos/bot/proj/git/ref/heads/typospider/hack": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"ref": "refs/heads/typospider/hack", "object": {"sha": "old"}}`)
+		},
+		"PATCH /repos/bot/proj/git/refs/heads/typospider/hack": func(w http.ResponseWriter, r *http.Request) {
+			decode(r, &update)
+			fmt.Fprint(w, `{"ref": "refs/heads/typospider/hack", "object": {"sha": "newcommit"}}`)
+		},
+		"POST /repos/up/proj/pulls": func(w http.ResponseWriter, r *http.Request) {
+			decode(r, &pull)
+			fmt.Fprint(w, `{"number": 7}`)
+		},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, ok := routes[r.Method+" "+r.URL.EscapedPath()]
+		if !ok {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+			return
+		}
+		route(w, r)
+	}))
+	defer server.Close()
+
+	vis, err := github.NewVisitor(true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vis.BaseURL = server.URL
+
+	script := &File{Path: "hack/run all.sh", SHA: "sha1", Mode: github.ModeExecutable}
+	source := &File{Path: "hack/main.go", SHA: "sha2"}
+	batch := &Batch{
+		Repo:  "up/proj",
+		Dir:   "hack",
+		Files: []*File{script, source},
+		Fixed: map[string]string{"sha1": "# Run all tests.\n", "sha2": "// Package main.\n"},
+		Edits: []*Edit{
+			{Old: "tets", New: "tests", Typo: &Typo{FileID: "sha1", Path: script.Path, Line: 1}},
+			{Old: "Pakage", New: "Package", Typo: &Typo{FileID: "sha2", Path: source.Path, Line: 1}},
+		},
+	}
+
+	// Fixes of another repository in the same index are never sent upstream.
+	other := &Batch{
+		Repo:  "other/proj",
+		Dir:   "hack",
+		Files: []*File{{Path: "hack/main.go", SHA: "sha3"}},
+		Fixed: map[string]string{"sha3": "// Package main.\n"},
+	}
+
+	prs, err := OpenPullRequests(vis, "up", "proj", []*Batch{other, batch})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prs) != 1 || prs[0].Number != 7 {
+		t.Fatalf("pull requests = %+v, want #7", prs)
+	}
+
+	// The changed file is left out, and the mode of the script is kept.
+	if tree.BaseTree != "basetree" || len(tree.Tree) != 1 {
+		t.Fatalf("tree = %+v, want one entry on basetree", tree)
+	}
+	entry := tree.Tree[0]
+	if entry.Path != script.Path || entry.Mode != github.ModeExecutable || entry.Content != "# Run all tests.\n" {
+		t.Errorf("tree entry = %+v", entry)
+	}
+	if commit.Tree != "newtree" || len(commit.Parents) != 1 || commit.Parents[0] != "base" {
+		t.Errorf("commit = %+v, want newtree on base", commit)
+	}
+	if update.SHA != "newcommit" || !update.Force {
+		t.Errorf("reference update = %+v, want forced to newcommit", update)
+	}
+	if pull["head"] != "bot:typospider/hack" || pull["base"] != "main" || pull["title"] != batch.Title() {
+		t.Errorf("pull request = %+v", pull)
+	}
+}
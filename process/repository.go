@@ -0,0 +1,191 @@
+package process
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/huangjiuyuan/typospider/github"
+)
+
+// RepositoryFilter selects the repositories to scan.
+type RepositoryFilter struct {
+	// Whether forks are scanned.
+	Forks bool
+	// Whether archived repositories are scanned.
+	Archived bool
+	// Primary language of the repositories to scan, like "Go", or empty for any language.
+	Language string
+	// Topics of the repositories to scan, of which a repository must have at least one, or empty for
+	// any topics.
+	Topics []string
+}
+
+// Match returns whether a repository is selected by the filter.
+func (filter *RepositoryFilter) Match(repo *github.Repository) bool {
+	if repo.Fork && !filter.Forks {
+		return false
+	}
+	if repo.Archived && !filter.Archived {
+		return false
+	}
+	if filter.Language != "" && !strings.EqualFold(repo.Language, filter.Language) {
+		return false
+	}
+	if len(filter.Topics) == 0 {
+		return true
+	}
+	for _, topic := range filter.Topics {
+		for _, t := range repo.Topics {
+			if strings.EqualFold(t, topic) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// OrganizationRepositories lists the repositories of an organization selected by the filter.
+func OrganizationRepositories(vis *github.Visitor, org string, filter *RepositoryFilter) ([]*github.Repository, error) {
+	repos := []*github.Repository{}
+	for page := 1; ; page++ {
+		list, err := vis.ListRepositories(org, page)
+		if err != nil {
+			return nil, fmt.Errorf("error on listing repositories of %s: %s", org, err)
+		}
+		for _, repo := range list {
+			if filter.Match(repo) {
+				repos = append(repos, repo)
+			}
+		}
+		if len(list) < github.PageSize {
+			break
+		}
+	}
+
+	return repos, nil
+}
+
+// ListedRepositories reads a list of repositories from a file, one "owner/name" per line, and returns
+// the repositories selected by the filter. Blank lines and lines starting with "#" are skipped.
+func ListedRepositories(vis *github.Visitor, path string, filter *RepositoryFilter) ([]*github.Repository, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error on opening repository list: %s", err)
+	}
+	defer f.Close()
+
+	repos := []*github.Repository{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.Split(line, "/")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("error on parsing repository %q: expected owner/name", line)
+		}
+
+		repo, err := vis.GetRepository(parts[0], parts[1])
+		if err != nil {
+			fmt.Printf("[Error] Get repository %s failed: %s\n", line, err)
+			continue
+		}
+		if filter.Match(repo) {
+			repos = append(repos, repo)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error on reading repository list: %s", err)
+	}
+
+	return repos, nil
+}
+
+// ProcessRepositories enqueues the blobs of the default branches of the repositories to the blob
+// queue one after another, so that all repositories share the budget of the visitor. The repositories
+// are downloaded as tarballs if Archives is set.
+func (proc *Processer) ProcessRepositories(repos []*github.Repository) {
+	for _, repo := range repos {
+		proc.summaryOf(repo.FullName)
+		parts := strings.SplitN(repo.FullName, "/", 2)
+		if len(parts) != 2 {
+			fmt.Printf("[Error] Invalid repository name %s\n", repo.FullName)
+			continue
+		}
+
+		var err error
+		if proc.Archives {
+			err = proc.processArchive(parts[0], parts[1], repo.DefaultBranch, repo.FullName)
+		} else {
+			err = proc.processTree(proc.Visitor.GetTreeURL(parts[0], parts[1], repo.DefaultBranch), repo.FullName)
+		}
+		if err != nil {
+			fmt.Printf("[Error] Processing repository %s failed: %s\n", repo.FullName, err)
+		}
+	}
+
+	// Send a signal that no more blob is produced.
+	proc.Finish()
+}
+
+// RepositorySummary aggregates the results of a repository.
+type RepositorySummary struct {
+	// Full name of the repository, like "owner/name".
+	Repo string
+	// Number of files checked.
+	Files int
+	// Number of files with typos.
+	FilesWithTypos int
+	// Number of typos found.
+	Typos int
+	// Number of files which are not checked, like symbolic links and binary files.
+	Skipped int
+}
+
+// Summaries returns the results aggregated per repository, sorted by the names of the repositories.
+func (proc *Processer) Summaries() []RepositorySummary {
+	proc.mu.Lock()
+	defer proc.mu.Unlock()
+
+	summaries := []RepositorySummary{}
+	for _, summary := range proc.summaries {
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Repo < summaries[j].Repo
+	})
+	return summaries
+}
+
+// summaryOf returns the summary of a repository, which is created if necessary. The caller must not
+// hold the lock.
+func (proc *Processer) summaryOf(repo string) *RepositorySummary {
+	proc.mu.Lock()
+	defer proc.mu.Unlock()
+
+	summary, ok := proc.summaries[repo]
+	if !ok {
+		summary = &RepositorySummary{Repo: repo}
+		proc.summaries[repo] = summary
+	}
+	return summary
+}
+
+// summarize adds the results of a checked file to the summary of its repository.
+func (proc *Processer) summarize(file *File) {
+	summary := proc.summaryOf(file.Repo)
+
+	proc.mu.Lock()
+	defer proc.mu.Unlock()
+	summary.Files++
+	if len(file.Fragments) > 0 {
+		summary.FilesWithTypos++
+	}
+	for _, frag := range file.Fragments {
+		summary.Typos += len(frag.Typos)
+	}
+}
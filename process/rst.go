@@ -0,0 +1,284 @@
+package process
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Patterns of the blocks of reStructuredText documents.
+var (
+	rstTableExp     = regexp.MustCompile(`^(\+[-=+]+\+|=+( +=+)+)\s*$`)
+	rstDirectiveExp = regexp.MustCompile(`^\.\.\s+([\w:-]+)::(\s+|$)`)
+	rstFieldExp     = regexp.MustCompile(`^:[^:\s][^:]*:(\s|$)`)
+	rstListExp      = regexp.MustCompile(`^([-*+•]|#\.|\d{1,9}[.)]|\(\d{1,9}\))\s+`)
+)
+
+// Directives whose content and arguments are prose, like admonitions.
+var rstProseDirectives = map[string]bool{
+	"admonition":     true,
+	"attention":      true,
+	"caution":        true,
+	"danger":         true,
+	"deprecated":     true,
+	"error":          true,
+	"hint":           true,
+	"important":      true,
+	"note":           true,
+	"seealso":        true,
+	"tip":            true,
+	"topic":          true,
+	"versionadded":   true,
+	"versionchanged": true,
+	"warning":        true,
+}
+
+// RSTExtractor extracts the section titles, paragraphs and list items of a reStructuredText document.
+// Literal blocks, code directives, comments, link targets, field lists and tables are skipped.
+type RSTExtractor struct{}
+
+// NewRSTExtractor returns a RSTExtractor with an error if necessary.
+func NewRSTExtractor() (*RSTExtractor, error) {
+	return &RSTExtractor{}, nil
+}
+
+// Extract extracts the prose of a reStructuredText document. Lines of a paragraph or a list item are
+// joined by spaces.
+func (ext *RSTExtractor) Extract(path string, text string) ([]*Token, error) {
+	tokens := []*Token{}
+
+	var token *Token
+	var lines int
+	var table bool
+	// Indentation of the paragraph introducing a literal block, or -1.
+	literal := -1
+	// Lines indented deeper than skip are skipped, like the content of a literal block.
+	skip := -1
+	for _, l := range sourceLines(text) {
+		indent, off := indentOf(l.text)
+		content := strings.TrimRight(l.text[off:], " \t")
+
+		if content == "" {
+			token, table = nil, false
+			continue
+		}
+		if skip >= 0 {
+			if indent > skip {
+				continue
+			}
+			skip = -1
+		}
+		// A paragraph ending with "::" introduces a literal block.
+		if literal >= 0 {
+			if token == nil && indent > literal {
+				skip = literal
+				literal = -1
+				continue
+			}
+			literal = -1
+		}
+		if table {
+			continue
+		}
+
+		switch {
+		case rstTableExp.MatchString(content):
+			token, table = nil, true
+		case isAdornment(content):
+			// An underline turns a paragraph of one line into a section title, and an overline or a
+			// transition is skipped.
+			if token != nil && token.Kind == KindParagraph && lines == 1 {
+				token.Kind = KindHeading
+			}
+			token = nil
+		case strings.HasPrefix(content, ".."):
+			token = nil
+			m := rstDirectiveExp.FindStringSubmatch(content)
+			if m == nil || !rstProseDirectives[m[1]] {
+				// Skip comments, link targets, footnotes and other directives with their content.
+				skip = indent
+				continue
+			}
+			if arg := content[len(m[0]):]; arg != "" {
+				token = &Token{Line: l.num, Kind: KindParagraph}
+				rstInline(token, arg, l.pos+off+len(m[0]))
+				tokens = append(tokens, token)
+				lines = 1
+			}
+		case rstFieldExp.MatchString(content), strings.HasPrefix(content, "|"):
+			// Skip field lists, like the options of directives, and line blocks and grid tables.
+			token = nil
+			skip = indent
+		case rstListExp.MatchString(content):
+			m := rstListExp.FindString(content)
+			token = &Token{Line: l.num, Kind: KindListItem}
+			tokens = append(tokens, token)
+			if rstLine(token, content[len(m):], l.pos+off+len(m)) {
+				literal = indent
+			}
+			lines = 1
+		default:
+			if token == nil {
+				token = &Token{Line: l.num, Kind: KindParagraph}
+				tokens = append(tokens, token)
+				lines = 0
+			} else {
+				token.Add(" ", -1)
+			}
+			if rstLine(token, content, l.pos+off) {
+				literal = indent
+			}
+			lines++
+		}
+	}
+
+	// Drop the tokens without any text, like paragraphs consisting of "::" only.
+	result := []*Token{}
+	for _, token := range tokens {
+		if strings.TrimSpace(token.Text) != "" {
+			result = append(result, token)
+		}
+	}
+	return result, nil
+}
+
+// isAdornment returns whether a line is an underline or overline of a section title, or a transition,
+// which repeats a punctuation character.
+func isAdornment(text string) bool {
+	if len(text) < 3 || !strings.ContainsRune("=-`:'\"~^_*+#<>.", rune(text[0])) {
+		return false
+	}
+	return strings.Count(text, text[:1]) == len(text)
+}
+
+// rstLine adds a line of a paragraph to the token, and returns whether the line introduces a literal
+// block. A trailing "::" is shown as ":", or is dropped if it follows a space.
+func rstLine(token *Token, text string, pos int) bool {
+	if !strings.HasSuffix(text, "::") {
+		rstInline(token, text, pos)
+		return false
+	}
+
+	text = text[:len(text)-1]
+	if t := strings.TrimSuffix(text, ":"); t == "" || strings.HasSuffix(t, " ") {
+		text = strings.TrimRight(t, " \t")
+	}
+	rstInline(token, text, pos)
+	return true
+}
+
+// rstInline adds the inline text of a reStructuredText line at the given byte offset of the source to
+// the token. Emphasis markers, link targets and footnote references are skipped, and inline literals,
+// interpreted text, roles and substitutions are replaced by a placeholder, so that they are never
+// checked as prose.
+func rstInline(token *Token, text string, pos int) {
+	plain := 0
+	flush := func(end int) {
+		if end > plain {
+			token.Add(text[plain:end], pos+plain)
+		}
+	}
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text):
+			// Escaped characters are kept without the backslash.
+			flush(i)
+			plain = i + 1
+			i += 2
+		case c == '`' || (c == ':' && rstRoleEnd(text[i:]) > 0):
+			// Roles like ":func:" prefix interpreted text.
+			start := i
+			if c == ':' {
+				start += rstRoleEnd(text[i:])
+			}
+			n := 1
+			if strings.HasPrefix(text[start:], "``") {
+				n = 2
+			}
+			end := strings.Index(text[start+n:], text[start:start+n])
+			if end < 0 {
+				i = start + n
+				continue
+			}
+			flush(i)
+			inner := text[start+n : start+n+end]
+			i = start + n + end + n
+
+			// Hyperlink references like "`text <url>`_" keep their text only.
+			if n == 1 && strings.HasPrefix(text[i:], "_") {
+				if lt := strings.LastIndex(inner, "<"); lt >= 0 && strings.HasSuffix(inner, ">") {
+					inner = strings.TrimRight(inner[:lt], " ")
+				}
+				token.Add(inner, pos+start+n)
+				i += len(text[i:]) - len(strings.TrimLeft(text[i:], "_"))
+			} else {
+				token.Add(codePlaceholder, -1)
+			}
+			plain = i
+		case c == '[' && strings.Contains(text[i:], "]_"):
+			// Skip footnote and citation references like "[1]_".
+			end := strings.Index(text[i:], "]_")
+			if strings.ContainsAny(text[i:i+end], " \t") {
+				i++
+				continue
+			}
+			// Drop the space before the reference, like in "text [1]_.".
+			flush(len(strings.TrimRight(text[:i], " ")))
+			i += end + 2
+			plain = i
+		case c == '|' && i+1 < len(text) && text[i+1] != ' ':
+			// Substitution references like "|name|" are read as code.
+			end := strings.IndexByte(text[i+1:], '|')
+			if end < 0 {
+				i++
+				continue
+			}
+			flush(i)
+			token.Add(codePlaceholder, -1)
+			i += end + 2
+			for i < len(text) && text[i] == '_' {
+				i++
+			}
+			plain = i
+		case c == '*':
+			flush(i)
+			i++
+			for i < len(text) && text[i] == '*' {
+				i++
+			}
+			plain = i
+		case c == '_' && i > 0 && isWordByte(text[i-1]) && (i+1 == len(text) || !isWordByte(text[i+1])):
+			// Skip the underscores of references like "name_".
+			flush(i)
+			for i < len(text) && text[i] == '_' {
+				i++
+			}
+			plain = i
+		default:
+			i++
+		}
+	}
+	flush(len(text))
+}
+
+// rstRoleEnd returns the length of a role like ":func:" followed by a backtick at the start of the
+// text, or 0 if the text does not start with a role.
+func rstRoleEnd(text string) int {
+	for i := 1; i < len(text); i++ {
+		c := text[i]
+		if c == ':' {
+			if i > 1 && i+1 < len(text) && text[i+1] == '`' {
+				return i + 1
+			}
+			if i+1 < len(text) && (text[i+1] >= 'a' && text[i+1] <= 'z') {
+				continue
+			}
+			return 0
+		}
+		if !isWordByte(c) && c != '-' && c != '.' && c != '+' {
+			return 0
+		}
+	}
+	return 0
+}
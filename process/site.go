@@ -0,0 +1,78 @@
+package process
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/huangjiuyuan/typospider/crawl"
+)
+
+// ProcessSite crawls a project website or its rendered documentation, and checks the prose of each
+// page. Each page with typos is indexed to the page index by its URL, and the typos are indexed to the
+// typo index with the CSS selectors of their blocks.
+func (proc *Processer) ProcessSite(site *crawl.Site, index string, typoIndex string) error {
+	// Load the triaged typos before the typo index is recreated.
+	triaged, err := proc.Elastic.TriagedTypos(typoIndex)
+	if err != nil {
+		fmt.Printf("[Warning] Load triaged typos failed: %s\n", err)
+	} else {
+		proc.triaged = triaged
+	}
+
+	err = proc.Elastic.CreateFileIndex(index)
+	if err != nil {
+		return err
+	}
+	err = proc.Elastic.CreateTypoIndex(typoIndex)
+	if err != nil {
+		return err
+	}
+
+	site.Found = func(page *crawl.Page) {
+		proc.processPage(page, index, typoIndex)
+	}
+	return crawl.CrawlSite(site)
+}
+
+// processPage checks the blocks of a page. The page is checked as a file whose text contains the
+// blocks on separate lines, and whose path is the page URL.
+func (proc *Processer) processPage(page *crawl.Page, index string, typoIndex string) {
+	hash := sha1.New()
+	hash.Write([]byte(page.URL))
+
+	tokens := []*Token{}
+	text := ""
+	for _, block := range page.Blocks {
+		if text != "" {
+			text += "\n"
+		}
+		token := &Token{
+			Line:   len(tokens) + 1,
+			Kind:   block.Kind,
+			Object: block.Selector,
+		}
+		token.Add(block.Text, len(text))
+		tokens = append(tokens, token)
+		text += block.Text
+	}
+
+	file, err := NewFile(page.URL, len(text), hex.EncodeToString(hash.Sum(nil)), page.URL, []byte(text))
+	if err != nil {
+		fmt.Printf("[Error] Create page %s failed: %s\n", page.URL, err)
+		return
+	}
+
+	err = proc.checkTokens(file, tokens, typoIndex)
+	if err != nil {
+		fmt.Printf("[Error] Check page %s failed: %s\n", page.URL, err)
+		return
+	}
+
+	if len(file.Fragments) > 0 {
+		_, err = proc.Elastic.IndexFile(index, *file)
+		if err != nil {
+			fmt.Printf("[Error] Index page %s failed: %s\n", page.URL, err)
+		}
+	}
+}
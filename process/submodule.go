@@ -0,0 +1,204 @@
+package process
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/huangjiuyuan/typospider/github"
+)
+
+// Reasons of skipping files which are not checked.
+const (
+	// SkipSymlink is the reason of skipping symbolic links.
+	SkipSymlink = "symlink"
+	// SkipSubmodule is the reason of skipping submodules which are not followed.
+	SkipSubmodule = "submodule"
+	// SkipLFS is the reason of skipping Git LFS pointers, whose content is stored out of the repository.
+	SkipLFS = "LFS pointer"
+	// SkipBinary is the reason of skipping binary files.
+	SkipBinary = "binary"
+)
+
+// First line of Git LFS pointer files.
+const lfsPointerPrefix = "version https://git-lfs.github.com/spec/v1\n"
+
+// Number of bytes sniffed for binary content, which is the same as git.
+const sniffLength = 8000
+
+// SkippedFile is a file which is not checked, with the reason.
+type SkippedFile struct {
+	// Full name of the repository, like "owner/name".
+	Repo string
+	// Path of the file in the repository.
+	Path string
+	// Reason of skipping the file, like SkipSymlink.
+	Reason string
+}
+
+// treeWalk collects the entries of a tree walked.
+type treeWalk struct {
+	// Blobs to check.
+	blobs []*github.Blob
+	// Submodules whose paths are relative to the repository root.
+	submodules []*github.Submodule
+	// URL of the .gitmodules blob at the repository root, or empty if there is none.
+	gitmodules string
+}
+
+// skip records a file which is not checked.
+func (proc *Processer) skip(repo string, path string, reason string) {
+	summary := proc.summaryOf(repo)
+
+	proc.mu.Lock()
+	defer proc.mu.Unlock()
+	summary.Skipped++
+	proc.skipped = append(proc.skipped, SkippedFile{
+		Repo:   repo,
+		Path:   path,
+		Reason: reason,
+	})
+}
+
+// Skipped returns the files which are not checked, in the order they are skipped.
+func (proc *Processer) Skipped() []SkippedFile {
+	proc.mu.Lock()
+	defer proc.mu.Unlock()
+	return append([]SkippedFile{}, proc.skipped...)
+}
+
+// processSubmodules follows the submodules of a repository into their repositories at the pinned
+// commits if Submodules is set, or records them as skipped otherwise. Only submodules hosted by the
+// same GitHub as the Visitor can be followed, and each commit of a repository is followed once.
+func (proc *Processer) processSubmodules(repo string, walk *treeWalk) error {
+	if len(walk.submodules) == 0 {
+		return nil
+	}
+	if !proc.Submodules {
+		for _, sm := range walk.submodules {
+			proc.skip(repo, strings.TrimPrefix(sm.Path, "/"), SkipSubmodule)
+		}
+		return nil
+	}
+
+	urls := map[string]string{}
+	if walk.gitmodules != "" {
+		data, err := proc.Provider.GetBlob(walk.gitmodules)
+		if err != nil {
+			fmt.Printf("[Error] Get .gitmodules of %s failed: %s\n", repo, err)
+		}
+		urls = parseGitmodules(data)
+	}
+
+	for _, sm := range walk.submodules {
+		p := strings.TrimPrefix(sm.Path, "/")
+		owner, name, ok := proc.submoduleRepository(urls[p], repo)
+		if !ok {
+			proc.skip(repo, p, SkipSubmodule)
+			continue
+		}
+
+		full := owner + "/" + name
+		proc.mu.Lock()
+		visited := proc.followed[full+"@"+sm.SHA]
+		proc.followed[full+"@"+sm.SHA] = true
+		proc.mu.Unlock()
+		if visited {
+			continue
+		}
+
+		err := proc.processTree(proc.Provider.GetTreeURL(owner, name, sm.SHA), full)
+		if err != nil {
+			fmt.Printf("[Error] Processing submodule %s of %s failed: %s\n", p, repo, err)
+		}
+	}
+	return nil
+}
+
+// submoduleRepository returns the owner and the name of the repository of a submodule by its URL in
+// .gitmodules, like "https://github.com/owner/name.git", "git@github.com:owner/name.git" or
+// "../name.git" relative to the repository of the superproject.
+func (proc *Processer) submoduleRepository(rawURL string, repo string) (string, string, bool) {
+	if rawURL == "" || proc.Visitor == nil || proc.Provider != proc.Visitor {
+		return "", "", false
+	}
+
+	var p string
+	switch {
+	case strings.HasPrefix(rawURL, "./") || strings.HasPrefix(rawURL, "../"):
+		if repo == "" {
+			return "", "", false
+		}
+		p = path.Join(repo, rawURL)
+	case strings.Contains(rawURL, "://"):
+		u, err := url.Parse(rawURL)
+		if err != nil || u.Hostname() != github.CredentialHost(proc.Visitor.BaseURL) {
+			return "", "", false
+		}
+		p = u.Path
+	default:
+		// SCP-like syntax of SSH URLs, like "git@github.com:owner/name.git".
+		parts := strings.SplitN(rawURL, ":", 2)
+		if len(parts) != 2 || strings.TrimPrefix(parts[0], "git@") != github.CredentialHost(proc.Visitor.BaseURL) {
+			return "", "", false
+		}
+		p = parts[1]
+	}
+
+	parts := strings.Split(strings.TrimSuffix(strings.Trim(p, "/"), ".git"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" || parts[0] == ".." {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// parseGitmodules parses the paths and the URLs of the submodules in .gitmodules, and returns the URLs
+// keyed by the paths.
+func parseGitmodules(data []byte) map[string]string {
+	urls := map[string]string{}
+	var p, u string
+	flush := func() {
+		if p != "" && u != "" {
+			urls[p] = u
+		}
+		p, u = "", ""
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			flush()
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch strings.TrimSpace(kv[0]) {
+		case "path":
+			p = strings.Trim(strings.TrimSpace(kv[1]), `"`)
+		case "url":
+			u = strings.Trim(strings.TrimSpace(kv[1]), `"`)
+		}
+	}
+	flush()
+	return urls
+}
+
+// isLFSPointer returns whether the data is a Git LFS pointer.
+func isLFSPointer(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(lfsPointerPrefix))
+}
+
+// isBinary returns whether the data is binary, which is the case if it contains a NUL byte near the
+// start like git assumes.
+func isBinary(data []byte) bool {
+	if len(data) > sniffLength {
+		data = data[:sniffLength]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
@@ -3,56 +3,124 @@ package process
 import (
 	"strings"
 	"text/scanner"
+	"unicode/utf8"
 )
 
 // Tokenizer is for tokenizing raw text.
 type Tokenizer struct{}
 
+const (
+	// KindComment is the kind of tokens of comments.
+	KindComment = "comment"
+	// KindString is the kind of tokens of string literals.
+	KindString = "string"
+	// KindHeading is the kind of tokens of headings of documents and pages.
+	KindHeading = "heading"
+	// KindParagraph is the kind of tokens of paragraphs and table cells of documents and pages.
+	KindParagraph = "paragraph"
+	// KindListItem is the kind of tokens of list items and definitions of documents and pages.
+	KindListItem = "list item"
+)
+
+// Word replacing code, like inline code of documents, in the text of tokens, which is read as a noun
+// and keeps sentences intact. It is not part of the source, so matches in it cannot be fixed.
+const codePlaceholder = "code"
+
+// Token is a block of text to be checked in a file, like adjacent comments.
+type Token struct {
+	// Line where the token starts.
+	Line int
+	// Kind of the token, like KindComment or KindString.
+	Kind string
+	// Declaration the token is associated with, like "func Foo".
+	Object string
+	// Name declared by the declaration, like "Foo", which its doc comment should start with.
+	Name string
+	// Text of the token, in which adjacent comments are joined by a space.
+	Text string
+	// Segments map the text of the token back to the source.
+	Segments []Segment
+}
+
+// Segment is a part of the token text which is copied verbatim from the source.
+type Segment struct {
+	// Byte offset of the segment in the token text.
+	Offset int
+	// Byte offset of the segment in the source.
+	Position int
+	// Length of the segment in bytes.
+	Length int
+}
+
 // NewTokenizer returns a Tokenizer with an error if necessary.
 func NewTokenizer() (*Tokenizer, error) {
 	return &Tokenizer{}, nil
 }
 
 // Tokenize the text.
-func (tokenizer *Tokenizer) Tokenize(text string) (map[int]string, error) {
+func (tokenizer *Tokenizer) Tokenize(text string) ([]*Token, error) {
 	var s scanner.Scanner
 	s.Init(strings.NewReader(text))
 	s.Mode = scanner.ScanIdents | scanner.ScanFloats | scanner.ScanChars | scanner.ScanStrings | scanner.ScanRawStrings | scanner.ScanComments
-	tokens := make(map[int]string)
+	tokens := []*Token{}
 
 	var line, column int
-	var token string
-	state, offset := 0, 1
-
+	var token *Token
 	for tok := s.Scan(); tok != scanner.EOF; tok = s.Scan() {
-		if tok == scanner.Comment {
-			if state == 0 {
-				offset = s.Line
-				token = s.TokenText()
-				state = 1
-			} else if state == 1 {
-				if s.Position.Line == line+1 && s.Position.Column == column {
-					state = 2
-					token = token + " " + s.TokenText()
-				} else {
-					tokens[offset] = token
-					offset = s.Line
-					token = s.TokenText()
-				}
-			} else {
-				if s.Position.Line == line+1 && s.Position.Column == column {
-					token = token + " " + s.TokenText()
-				} else {
-					state = 1
-					tokens[offset] = token
-					offset = s.Line
-					token = s.TokenText()
-				}
-			}
-			line, column = s.Position.Line, s.Position.Column
+		if tok != scanner.Comment {
+			continue
 		}
+
+		// Join the comment to the current token if it starts on the next line at the same column.
+		if token != nil && s.Position.Line == line+1 && s.Position.Column == column {
+			token.Add(" ", -1)
+		} else {
+			token = &Token{Line: s.Position.Line, Kind: KindComment}
+			tokens = append(tokens, token)
+		}
+		token.Add(s.TokenText(), s.Position.Offset)
+		line, column = s.Position.Line, s.Position.Column
 	}
-	tokens[offset] = token
 
 	return tokens, nil
 }
+
+// Add appends a text to the token. The text is copied from the given byte offset of the source, or
+// is not part of the source if the offset is negative.
+func (token *Token) Add(text string, position int) {
+	if position >= 0 {
+		token.Segments = append(token.Segments, Segment{
+			Offset:   len(token.Text),
+			Position: position,
+			Length:   len(text),
+		})
+	}
+	token.Text += text
+}
+
+// Position maps a byte range of the token text to the source. It returns false if the range is not
+// copied verbatim from a single segment of the source.
+func (token *Token) Position(offset int, length int) (int, bool) {
+	for _, seg := range token.Segments {
+		if offset >= seg.Offset && offset+length <= seg.Offset+seg.Length {
+			return seg.Position + offset - seg.Offset, true
+		}
+	}
+	return -1, false
+}
+
+// byteOffset converts an offset counted in UTF-16 code units, which is how LanguageTool counts
+// characters, to a byte offset in the text.
+func byteOffset(text string, units int) int {
+	offset := 0
+	for units > 0 && offset < len(text) {
+		r, size := utf8.DecodeRuneInString(text[offset:])
+		if r >= 0x10000 {
+			units -= 2
+		} else {
+			units--
+		}
+		offset += size
+	}
+	return offset
+}
@@ -0,0 +1,57 @@
+package process
+
+import (
+	"fmt"
+
+	"github.com/olivere/elastic"
+)
+
+const (
+	// TypoPending marks a typo which has not been triaged yet.
+	TypoPending = "pending"
+	// TypoAccepted marks a typo which has been confirmed as a real error.
+	TypoAccepted = "accepted"
+	// TypoIgnored marks a typo which has been triaged as a false positive.
+	TypoIgnored = "ignored"
+)
+
+// TriageTypo records a triage decision on a typo with the reason and the author of the decision.
+// An ignored typo is marked invalid and suppressed in subsequent scans.
+func (es *Elastic) TriageTypo(index string, id string, status string, reason string, author string) (*Typo, error) {
+	if status != TypoAccepted && status != TypoIgnored {
+		return nil, fmt.Errorf("unknown triage status %s", status)
+	}
+
+	typo, err := es.LoadTypo(index, id)
+	if err != nil {
+		return nil, err
+	}
+
+	typo.Valid = status == TypoAccepted
+	typo.Status = status
+	typo.Reason = reason
+	typo.Author = author
+	_, err = es.UpdateTypo(index, id, *typo)
+	if err != nil {
+		return nil, err
+	}
+
+	return typo, nil
+}
+
+// TriagedTypos returns the typos triaged as accepted or ignored, keyed by their fingerprints, so that
+// the decisions survive the typo index being recreated by a rescan.
+func (es *Elastic) TriagedTypos(index string) (map[string]*Typo, error) {
+	triaged := make(map[string]*Typo)
+	for _, status := range []string{TypoAccepted, TypoIgnored} {
+		typos, err := es.SearchTypos(index, elastic.NewTermQuery("status", status))
+		if err != nil {
+			return nil, err
+		}
+		for _, typo := range typos {
+			triaged[typo.SHA] = typo
+		}
+	}
+
+	return triaged, nil
+}
@@ -3,6 +3,7 @@ package process
 import (
 	"crypto/sha1"
 	"encoding/hex"
+	"strings"
 
 	"github.com/huangjiuyuan/typospider/language"
 )
@@ -12,9 +13,16 @@ type File struct {
 	Size      int        `json:"size"`
 	SHA       string     `json:"sha"`
 	URL       string     `json:"url"`
+	Repo      string     `json:"repo"`
 	Fragments []Fragment `json:"fragments"`
 	Data      string     `json:"data"`
 	Valid     bool       `json:"valid"`
+	// Encoding of the blob, like EncodingUTF16LE, or empty for UTF-8. Data is always UTF-8.
+	Encoding string `json:"encoding"`
+	// Whether Data is omitted from the index to save space.
+	Omitted bool `json:"omitted"`
+	// File mode of the blob in its tree, like github.ModeExecutable, or empty if it is unknown.
+	Mode string `json:"mode"`
 }
 
 type Fragment struct {
@@ -27,6 +35,25 @@ type Typo struct {
 	FileID string         `json:"fileId"`
 	Match  language.Match `json:"match"`
 	Valid  bool           `json:"valid"`
+	Status string         `json:"status"`
+	Reason string         `json:"reason"`
+	Author string         `json:"author"`
+	// Location of the typo in the file. Position and Length are counted in bytes, and Position is
+	// -1 if the typo cannot be mapped back to the source.
+	Path     string `json:"path"`
+	Line     int    `json:"line"`
+	Position int    `json:"position"`
+	Length   int    `json:"length"`
+	// Erroneous text of the typo.
+	Text string `json:"text"`
+	// Language code which the typo is checked in.
+	Language string `json:"language"`
+	// Kind of the text containing the typo, like "comment" or "string".
+	Kind string `json:"kind"`
+	// Declaration the text is associated with, like "func Foo".
+	Object string `json:"object"`
+	// Full name of the repository containing the typo, like "owner/name".
+	Repo string `json:"repo"`
 }
 
 func NewFile(path string, size int, sha string, url string, data []byte) (*File, error) {
@@ -41,10 +68,7 @@ func NewFile(path string, size int, sha string, url string, data []byte) (*File,
 }
 
 func (frag *Fragment) AddTypo(fileId string, match language.Match) (*Typo, error) {
-	text := match.Context.Text
-	hash := sha1.New()
-	hash.Write([]byte(text))
-	sha := hex.EncodeToString(hash.Sum(nil))
+	sha := Fingerprint(match)
 	frag.Typos = append(frag.Typos, sha)
 
 	typo := &Typo{
@@ -52,7 +76,36 @@ func (frag *Fragment) AddTypo(fileId string, match language.Match) (*Typo, error
 		FileID: fileId,
 		Match:  match,
 		Valid:  true,
+		Status: TypoPending,
 	}
 
 	return typo, nil
 }
+
+// Fingerprint returns the identifier of a typo, which is stable across scans.
+func Fingerprint(match language.Match) string {
+	text := match.Context.Text
+	hash := sha1.New()
+	hash.Write([]byte(text))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Locate sets the location of the typo by mapping its match in the token back to the file.
+func (typo *Typo) Locate(file *File, token *Token) {
+	offset := byteOffset(token.Text, typo.Match.Offset)
+	length := byteOffset(token.Text[offset:], typo.Match.Length)
+
+	typo.Path = file.Path
+	typo.Repo = file.Repo
+	typo.Kind = token.Kind
+	typo.Object = token.Object
+	typo.Line = token.Line
+	typo.Text = token.Text[offset : offset+length]
+	typo.Position = -1
+	typo.Length = 0
+	if pos, ok := token.Position(offset, length); ok {
+		typo.Line = strings.Count(file.Data[:pos], "\n") + 1
+		typo.Position = pos
+		typo.Length = length
+	}
+}
@@ -0,0 +1,199 @@
+package spell
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/huangjiuyuan/typospider/language"
+)
+
+const (
+	// RuleID is the identifier of the spelling rule reported by the Checker.
+	RuleID = "HUNSPELL_RULE"
+	// Number of characters on each side of an error in its context.
+	contextSize = 40
+	// Maximum number of suggestions of a misspelled word.
+	maxSuggestions = 5
+)
+
+// Checker is a language.Checker which finds spelling mistakes with a Hunspell dictionary, without
+// any external service.
+type Checker struct {
+	// Dictionary of the words.
+	Dictionary *Dictionary
+	// Language code of the dictionary, like "en-US".
+	Language string
+}
+
+// NewChecker returns a Checker loading the Hunspell dictionary at a path without extension, like
+// "/usr/share/hunspell/en_US", with an error if necessary.
+func NewChecker(path string) (*Checker, error) {
+	path = strings.TrimSuffix(strings.TrimSuffix(path, ".dic"), ".aff")
+	dict, err := Load(path+".aff", path+".dic")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Checker{
+		Dictionary: dict,
+		Language:   strings.Replace(filepath.Base(path), "_", "-", -1),
+	}, nil
+}
+
+// CheckWithOptions checks the spelling of the text of the options. Only the text parts of annotated
+// text are checked. Options other than the text and language are ignored.
+func (c *Checker) CheckWithOptions(opts *language.CheckOptions) (*language.CheckResult, error) {
+	if opts.Language != language.AutoLanguage && !c.supports(opts.Language) {
+		return nil, fmt.Errorf("language %s is not supported by dictionary %s", opts.Language, c.Language)
+	}
+
+	// Collect the text parts with their byte offsets in the whole text.
+	var text string
+	var parts [][2]int
+	if opts.Data != nil {
+		for _, a := range opts.Data.Annotation {
+			if a.Markup != "" {
+				text += a.Markup
+				continue
+			}
+			parts = append(parts, [2]int{len(text), len(text) + len(a.Text)})
+			text += a.Text
+		}
+	} else {
+		text = opts.Text
+		parts = [][2]int{{0, len(text)}}
+	}
+
+	matches := []*language.Match{}
+	for _, part := range parts {
+		for _, w := range words(text[part[0]:part[1]]) {
+			start, end := part[0]+w[0], part[0]+w[1]
+			word := text[start:end]
+			if c.Dictionary.Contains(word) {
+				continue
+			}
+			matches = append(matches, c.newMatch(text, start, end))
+		}
+	}
+
+	return &language.CheckResult{
+		Software: language.Software{
+			Name: "Typospider Hunspell",
+		},
+		Language: language.Language{
+			Name: c.Language,
+			Code: c.Language,
+		},
+		Matches: matches,
+	}, nil
+}
+
+// Languages returns the language of the dictionary.
+func (c *Checker) Languages() (*language.LanguagesResult, error) {
+	code := c.Language
+	if i := strings.Index(code, "-"); i >= 0 {
+		code = code[:i]
+	}
+	return &language.LanguagesResult{
+		{
+			Name:     c.Language,
+			Code:     code,
+			LongCode: c.Language,
+		},
+	}, nil
+}
+
+// supports returns whether the language code, like "en" or "en-US", matches the dictionary.
+func (c *Checker) supports(lang string) bool {
+	lr, _ := c.Languages()
+	return lr.Supports(lang)
+}
+
+// newMatch returns a match of the misspelled word at [start, end) of the text.
+func (c *Checker) newMatch(text string, start int, end int) *language.Match {
+	word := text[start:end]
+	replacements := []*language.Replacement{}
+	for _, s := range c.Dictionary.Suggest(word, maxSuggestions) {
+		value := s
+		replacements = append(replacements, &language.Replacement{Value: &value})
+	}
+
+	// The context contains at most contextSize characters on each side of the word.
+	ctxStart, ctxEnd := start, end
+	for i := 0; i < contextSize && ctxStart > 0; i++ {
+		_, size := utf8.DecodeLastRuneInString(text[:ctxStart])
+		ctxStart -= size
+	}
+	for i := 0; i < contextSize && ctxEnd < len(text); i++ {
+		_, size := utf8.DecodeRuneInString(text[ctxEnd:])
+		ctxEnd += size
+	}
+
+	short := "Spelling mistake"
+	category, name := "TYPOS", "Possible Typo"
+	issue := "misspelling"
+	return &language.Match{
+		Message:      "Possible spelling mistake found.",
+		ShortMessage: &short,
+		Offset:       language.UTF16Len(text[:start]),
+		Length:       language.UTF16Len(word),
+		Replacements: replacements,
+		Context: language.Context{
+			Text:   text[ctxStart:ctxEnd],
+			Offset: language.UTF16Len(text[ctxStart:start]),
+			Length: language.UTF16Len(word),
+		},
+		Sentence: text[ctxStart:ctxEnd],
+		Rule: language.Rule{
+			ID:          RuleID,
+			Description: "Possible spelling mistake",
+			IssueType:   &issue,
+			Category: language.Category{
+				ID:   &category,
+				Name: &name,
+			},
+		},
+	}
+}
+
+// words returns the byte ranges of the words in the text. A word is a sequence of letters, which may
+// contain apostrophes inside. Hyphenated words are split into parts, and words with digits or
+// underscores are skipped since they are usually identifiers.
+func words(text string) [][2]int {
+	type char struct {
+		r   rune
+		pos int
+	}
+	chars := []char{}
+	for i, r := range text {
+		chars = append(chars, char{r, i})
+	}
+	chars = append(chars, char{' ', len(text)})
+
+	isWordRune := func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+	}
+
+	ranges := [][2]int{}
+	start, skip := -1, false
+	for i, c := range chars {
+		inner := (c.r == '\'' || c.r == '’') && start >= 0 && i+1 < len(chars) && unicode.IsLetter(chars[i+1].r)
+		if isWordRune(c.r) || inner {
+			if start < 0 {
+				start = i
+			}
+			if unicode.IsDigit(c.r) || c.r == '_' {
+				skip = true
+			}
+			continue
+		}
+		if start >= 0 && !skip {
+			ranges = append(ranges, [2]int{chars[start].pos, c.pos})
+		}
+		start, skip = -1, false
+	}
+	return ranges
+}
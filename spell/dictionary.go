@@ -0,0 +1,413 @@
+package spell
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Dictionary is a word list loaded from Hunspell dictionary files, which contains every word form
+// generated by the affix rules.
+type Dictionary struct {
+	// Characters tried for suggestions, most frequent first.
+	Try string
+	// Pairs of common misspellings and their corrections used for suggestions.
+	Replacements [][2]string
+
+	// All valid word forms.
+	words map[string]bool
+	// Word forms which must not be accepted.
+	forbidden map[string]bool
+}
+
+// affix is a prefix or suffix rule of an affix file.
+type affix struct {
+	// Whether it is a prefix rule.
+	prefix bool
+	// Whether it can be combined with a rule of the other kind.
+	cross bool
+	// Characters stripped from the word.
+	strip string
+	// Characters added to the word.
+	add string
+	// Flags of the rules applicable to the affixed word.
+	flags []string
+	// Condition the word must meet.
+	condition *regexp.Regexp
+}
+
+// affixFile contains the rules parsed from an affix file.
+type affixFile struct {
+	// Encoding of the dictionary files.
+	encoding string
+	// Type of the flags, which is "short", "long", "num" or "UTF-8".
+	flagType string
+	// Characters tried for suggestions.
+	try string
+	// Replacements used for suggestions.
+	rep [][2]string
+	// Flag of the words which are valid with an affix only.
+	needAffix string
+	// Flag of the forbidden words.
+	forbidden string
+	// Affix rules keyed by their flags.
+	rules map[string][]*affix
+}
+
+// Load loads a dictionary from a Hunspell affix file and a dictionary file, like "en_US.aff" and
+// "en_US.dic".
+func Load(affPath string, dicPath string) (*Dictionary, error) {
+	af, err := os.Open(affPath)
+	if err != nil {
+		return nil, fmt.Errorf("error on opening affix file: %s", err)
+	}
+	defer af.Close()
+
+	aff, err := parseAffix(af)
+	if err != nil {
+		return nil, fmt.Errorf("error on parsing affix file %s: %s", affPath, err)
+	}
+
+	df, err := os.Open(dicPath)
+	if err != nil {
+		return nil, fmt.Errorf("error on opening dictionary file: %s", err)
+	}
+	defer df.Close()
+
+	dict := &Dictionary{
+		Try:          aff.try,
+		Replacements: aff.rep,
+		words:        make(map[string]bool),
+		forbidden:    make(map[string]bool),
+	}
+	err = dict.parseDic(df, aff)
+	if err != nil {
+		return nil, fmt.Errorf("error on parsing dictionary file %s: %s", dicPath, err)
+	}
+
+	return dict, nil
+}
+
+// Contains returns whether a word is spelled correctly. A capitalized or upper case word is also
+// accepted if its lower case or capitalized form is in the dictionary.
+func (dict *Dictionary) Contains(word string) bool {
+	if dict.forbidden[word] {
+		return false
+	}
+	if dict.words[word] {
+		return true
+	}
+
+	lower := strings.ToLower(word)
+	if isCapitalized(word) {
+		return dict.words[lower] && !dict.forbidden[lower]
+	}
+	if strings.ToUpper(word) == word {
+		return dict.words[lower] || dict.words[capitalize(lower)]
+	}
+	return false
+}
+
+// Len returns the number of word forms in the dictionary.
+func (dict *Dictionary) Len() int {
+	return len(dict.words)
+}
+
+// Add adds a word to the dictionary, like a project specific term.
+func (dict *Dictionary) Add(word string) {
+	dict.words[word] = true
+	delete(dict.forbidden, word)
+}
+
+// parseAffix parses the rules of an affix file.
+func parseAffix(r io.Reader) (*affixFile, error) {
+	aff := &affixFile{
+		encoding: "UTF-8",
+		flagType: "short",
+		rules:    make(map[string][]*affix),
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	// Remaining lines of the current affix table.
+	var remaining int
+	var header []string
+	for scanner.Scan() {
+		line := aff.decode(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		if remaining > 0 && fields[0] == header[0] {
+			remaining--
+			err := aff.parseRule(header, fields)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		switch fields[0] {
+		case "SET":
+			if len(fields) > 1 {
+				aff.encoding = strings.ToUpper(fields[1])
+			}
+		case "FLAG":
+			if len(fields) > 1 {
+				aff.flagType = fields[1]
+			}
+		case "TRY":
+			if len(fields) > 1 {
+				aff.try = fields[1]
+			}
+		case "NEEDAFFIX":
+			if len(fields) > 1 {
+				aff.needAffix = fields[1]
+			}
+		case "FORBIDDENWORD":
+			if len(fields) > 1 {
+				aff.forbidden = fields[1]
+			}
+		case "REP":
+			// The first REP line is the number of replacements.
+			if len(fields) > 2 {
+				aff.rep = append(aff.rep, [2]string{
+					strings.Replace(fields[1], "_", " ", -1),
+					strings.Replace(fields[2], "_", " ", -1),
+				})
+			}
+		case "PFX", "SFX":
+			if len(fields) < 4 {
+				return nil, fmt.Errorf("invalid affix header %q", line)
+			}
+			n, err := strconv.Atoi(fields[3])
+			if err != nil {
+				return nil, fmt.Errorf("invalid affix header %q", line)
+			}
+			header, remaining = fields, n
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return aff, nil
+}
+
+// parseRule parses an affix rule like "SFX D y ied [^aeiou]y" under the header like "SFX D Y 4".
+func (aff *affixFile) parseRule(header []string, fields []string) error {
+	if len(fields) < 4 {
+		return fmt.Errorf("invalid affix rule %q", strings.Join(fields, " "))
+	}
+
+	rule := &affix{
+		prefix: header[0] == "PFX",
+		cross:  header[2] == "Y",
+	}
+	if fields[2] != "0" {
+		rule.strip = fields[2]
+	}
+	add := fields[3]
+	if i := strings.Index(add, "/"); i >= 0 {
+		rule.flags = aff.parseFlags(add[i+1:])
+		add = add[:i]
+	}
+	if add != "0" {
+		rule.add = add
+	}
+
+	condition := "."
+	if len(fields) > 4 {
+		condition = fields[4]
+	}
+	var exp string
+	if rule.prefix {
+		exp = "^" + condition
+	} else {
+		exp = condition + "$"
+	}
+	re, err := regexp.Compile(exp)
+	if err != nil {
+		return fmt.Errorf("invalid affix condition %q: %s", condition, err)
+	}
+	rule.condition = re
+
+	aff.rules[fields[1]] = append(aff.rules[fields[1]], rule)
+	return nil
+}
+
+// parseFlags splits the flags of a word or an affix according to the flag type.
+func (aff *affixFile) parseFlags(s string) []string {
+	flags := []string{}
+	switch aff.flagType {
+	case "long":
+		for i := 0; i+1 < len(s); i += 2 {
+			flags = append(flags, s[i:i+2])
+		}
+	case "num":
+		for _, f := range strings.Split(s, ",") {
+			if f != "" {
+				flags = append(flags, f)
+			}
+		}
+	default:
+		for _, r := range s {
+			flags = append(flags, string(r))
+		}
+	}
+	return flags
+}
+
+// decode converts a line of the dictionary files to UTF-8.
+func (aff *affixFile) decode(line string) string {
+	if aff.encoding == "UTF-8" || utf8.ValidString(line) && !strings.HasPrefix(aff.encoding, "ISO8859") {
+		return line
+	}
+
+	// Treat the single byte encodings as Latin-1, which covers the common Western dictionaries.
+	runes := make([]rune, len(line))
+	for i := 0; i < len(line); i++ {
+		runes[i] = rune(line[i])
+	}
+	return string(runes)
+}
+
+// parseDic parses the words of a dictionary file and expands them with the affix rules.
+func (dict *Dictionary) parseDic(r io.Reader, aff *affixFile) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	first := true
+	for scanner.Scan() {
+		line := aff.decode(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		// The first line is the approximate number of words.
+		if first {
+			first = false
+			if _, err := strconv.Atoi(strings.TrimSpace(line)); err == nil {
+				continue
+			}
+		}
+
+		// Skip the morphological fields after the word.
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			line = line[:i]
+		}
+		if line == "" {
+			continue
+		}
+
+		word, flags := line, []string{}
+		if i := strings.LastIndex(line, "/"); i > 0 && line[i-1] != '\\' {
+			word, flags = line[:i], aff.parseFlags(line[i+1:])
+		}
+		word = strings.Replace(word, `\/`, "/", -1)
+		dict.expand(word, flags, aff)
+	}
+
+	return scanner.Err()
+}
+
+// expand adds a word and all its forms generated by the affix rules of the flags.
+func (dict *Dictionary) expand(word string, flags []string, aff *affixFile) {
+	if hasFlag(flags, aff.forbidden) {
+		dict.forbidden[word] = true
+		return
+	}
+	if !hasFlag(flags, aff.needAffix) {
+		dict.words[word] = true
+	}
+
+	for _, flag := range flags {
+		for _, sfx := range aff.rules[flag] {
+			if sfx.prefix {
+				continue
+			}
+			form, ok := sfx.apply(word)
+			if !ok {
+				continue
+			}
+			dict.words[form] = true
+
+			// Apply the suffixes of the suffix, and the prefixes which can be combined.
+			for _, f := range sfx.flags {
+				for _, rule := range aff.rules[f] {
+					if next, ok := rule.apply(form); ok {
+						dict.words[next] = true
+					}
+				}
+			}
+			if !sfx.cross {
+				continue
+			}
+			for _, f := range flags {
+				for _, pfx := range aff.rules[f] {
+					if !pfx.prefix || !pfx.cross {
+						continue
+					}
+					if next, ok := pfx.apply(form); ok {
+						dict.words[next] = true
+					}
+				}
+			}
+		}
+	}
+
+	for _, flag := range flags {
+		for _, pfx := range aff.rules[flag] {
+			if !pfx.prefix {
+				continue
+			}
+			if form, ok := pfx.apply(word); ok {
+				dict.words[form] = true
+			}
+		}
+	}
+}
+
+// apply applies the rule to a word, and returns false if the word does not meet the condition.
+func (rule *affix) apply(word string) (string, bool) {
+	if !rule.condition.MatchString(word) {
+		return "", false
+	}
+	if rule.prefix {
+		if !strings.HasPrefix(word, rule.strip) {
+			return "", false
+		}
+		return rule.add + word[len(rule.strip):], true
+	}
+	if !strings.HasSuffix(word, rule.strip) {
+		return "", false
+	}
+	return word[:len(word)-len(rule.strip)] + rule.add, true
+}
+
+// hasFlag returns whether the flags contain the flag.
+func hasFlag(flags []string, flag string) bool {
+	if flag == "" {
+		return false
+	}
+	for _, f := range flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// isCapitalized returns whether only the first letter of the word is in upper case.
+func isCapitalized(word string) bool {
+	r, size := utf8.DecodeRuneInString(word)
+	return unicode.IsUpper(r) && strings.ToLower(word[size:]) == word[size:]
+}
+
+// capitalize returns the word with the first letter in upper case.
+func capitalize(word string) string {
+	r, size := utf8.DecodeRuneInString(word)
+	return string(unicode.ToUpper(r)) + word[size:]
+}
@@ -0,0 +1,56 @@
+package spell
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func loadTestDictionary(t *testing.T) *Dictionary {
+	dict, err := Load(filepath.Join("testdata", "en.aff"), filepath.Join("testdata", "en.dic"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dict
+}
+
+func TestLoad(t *testing.T) {
+	dict := loadTestDictionary(t)
+	if dict.Try != "esianrtolcdugmphbkw" {
+		t.Errorf("Try = %q", dict.Try)
+	}
+	if want := [][2]string{{"f", "ph"}}; !reflect.DeepEqual(dict.Replacements, want) {
+		t.Errorf("Replacements = %q, want %q", dict.Replacements, want)
+	}
+
+	tests := []struct {
+		word string
+		want bool
+	}{
+		{word: "carry", want: true},
+		{word: "carried", want: true},
+		{word: "carryed", want: false},
+		{word: "tied", want: true},
+		{word: "tieed", want: false},
+		{word: "worked", want: true},
+		// Suffixes are applied to the words generated by suffixes.
+		{word: "worker", want: true},
+		{word: "workers", want: true},
+		{word: "works", want: false},
+		// Prefixes are combined with suffixes which allow it.
+		{word: "unworked", want: true},
+		{word: "untried", want: true},
+		{word: "unphone", want: false},
+		{word: "kind", want: false},
+		{word: "unkind", want: true},
+		{word: "irregardless", want: false},
+		{word: "Carried", want: true},
+		{word: "CARRIED", want: true},
+		{word: "cARRIED", want: false},
+	}
+	for _, test := range tests {
+		if got := dict.Contains(test.word); got != test.want {
+			t.Errorf("Contains(%q) = %v, want %v", test.word, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package spell
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	// Alphabet tried for suggestions if the affix file does not specify one.
+	defaultTry = "esianrtolcdugmphbyfvkwz'"
+	// Maximum length of a word for which words two edits away are suggested.
+	maxEdits2Length = 10
+)
+
+// Suggest returns at most n suggestions for a misspelled word, ordered by edit distance.
+func (dict *Dictionary) Suggest(word string, n int) []string {
+	suggestions := []string{}
+	seen := map[string]bool{word: true}
+	add := func(candidate string) bool {
+		if seen[candidate] {
+			return false
+		}
+		seen[candidate] = true
+		if dict.Contains(candidate) {
+			suggestions = append(suggestions, matchCase(word, candidate))
+		}
+		return len(suggestions) >= n
+	}
+
+	// Common misspellings first, then words one edit away.
+	for _, rep := range dict.Replacements {
+		if strings.Contains(word, rep[0]) && add(strings.Replace(word, rep[0], rep[1], 1)) {
+			return suggestions
+		}
+	}
+	lower := strings.ToLower(word)
+	edits := dict.edits(lower)
+	for _, candidate := range edits {
+		if add(candidate) {
+			return suggestions
+		}
+	}
+	if len(suggestions) > 0 {
+		return suggestions
+	}
+
+	// Words two edits away, only if there is nothing closer. Long words are skipped since the number
+	// of candidates grows quadratically.
+	if len([]rune(lower)) > maxEdits2Length {
+		return suggestions
+	}
+	for _, edit := range edits {
+		for _, candidate := range dict.edits(edit) {
+			if add(candidate) {
+				return suggestions
+			}
+		}
+	}
+	return suggestions
+}
+
+// edits returns all strings one edit away from the word, which are produced by swapping adjacent
+// characters, deleting, replacing or inserting a character.
+func (dict *Dictionary) edits(word string) []string {
+	try := dict.Try
+	if try == "" {
+		try = defaultTry
+	}
+	runes := []rune(word)
+	edits := []string{}
+
+	for i := 0; i+1 < len(runes); i++ {
+		swapped := append([]rune{}, runes...)
+		swapped[i], swapped[i+1] = swapped[i+1], swapped[i]
+		edits = append(edits, string(swapped))
+	}
+	for i := range runes {
+		edits = append(edits, string(runes[:i])+string(runes[i+1:]))
+	}
+	for i := range runes {
+		for _, c := range try {
+			if c != runes[i] {
+				edits = append(edits, string(runes[:i])+string(c)+string(runes[i+1:]))
+			}
+		}
+	}
+	for i := 0; i <= len(runes); i++ {
+		for _, c := range try {
+			edits = append(edits, string(runes[:i])+string(c)+string(runes[i:]))
+		}
+	}
+
+	return edits
+}
+
+// matchCase returns the suggestion in the case of the misspelled word.
+func matchCase(word string, suggestion string) string {
+	if len(word) > 1 && strings.ToUpper(word) == word {
+		return strings.ToUpper(suggestion)
+	}
+	for _, r := range word {
+		if unicode.IsUpper(r) {
+			return capitalize(suggestion)
+		}
+		break
+	}
+	return suggestion
+}
@@ -0,0 +1,32 @@
+package spell
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSuggest(t *testing.T) {
+	dict := loadTestDictionary(t)
+	tests := []struct {
+		word string
+		n    int
+		want []string
+	}{
+		// Common misspellings of the affix file come first.
+		{word: "fones", n: 5, want: []string{"phones"}},
+		// Swapped characters rank before deleted ones.
+		{word: "tide", n: 5, want: []string{"tied", "tie"}},
+		{word: "tide", n: 1, want: []string{"tied"}},
+		{word: "Tide", n: 5, want: []string{"Tied", "Tie"}},
+		{word: "WROK", n: 5, want: []string{"WORK"}},
+		// Words two edits away are suggested only if there is nothing closer.
+		{word: "wrkd", n: 1, want: []string{"work"}},
+		{word: "xyzzyxyzzyx", n: 5, want: []string{}},
+	}
+
+	for _, test := range tests {
+		if got := dict.Suggest(test.word, test.n); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Suggest(%q, %d) = %q, want %q", test.word, test.n, got, test.want)
+		}
+	}
+}
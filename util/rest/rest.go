@@ -0,0 +1,76 @@
+// Package rest sends requests to the REST APIs of the hosting services other than GitHub.
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/huangjiuyuan/typospider/github"
+)
+
+// UserAgent is the User-Agent of the requests.
+const UserAgent = "typospider"
+
+// Maximum number of bytes of an error response included in the error.
+const maxErrorBody = 1024
+
+// Open sends a GET request with the header, and returns the body of the response to be streamed. The
+// caller must close it. The client defaults to http.DefaultClient if it is nil, and responses other
+// than 2xx are returned as errors with their bodies.
+func Open(client *http.Client, u string, header http.Header) (io.ReadCloser, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error on creating new request: %s", err)
+	}
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	req.Header.Set("User-Agent", UserAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error on requesting %s: %s", u, github.Redact(err.Error()))
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return nil, fmt.Errorf("error on requesting %s: %s: %s", u, resp.Status, github.Redact(string(b)))
+	}
+	return resp.Body, nil
+}
+
+// Get sends a GET request with the header, and returns the body of the response.
+func Get(client *http.Client, u string, header http.Header) ([]byte, error) {
+	body, err := Open(client, u, header)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	b, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("error on reading a response: %s", err)
+	}
+	return b, nil
+}
+
+// GetJSON sends a GET request with the header, and parses the JSON response into out.
+func GetJSON(client *http.Client, u string, header http.Header, out interface{}) error {
+	b, err := Get(client, u, header)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(b, out)
+	if err != nil {
+		return fmt.Errorf("error on parsing a response: %s", err)
+	}
+	return nil
+}